@@ -3,8 +3,12 @@ module github.com/brandonnguyenn27/ascii-converter
 go 1.23
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/gofiber/fiber/v2 v2.52.10
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
+	github.com/u2takey/ffmpeg-go v0.5.0
+	golang.org/x/image v0.24.0
+	golang.org/x/term v0.27.0
 )
 
 require (
@@ -17,7 +21,6 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
-	github.com/u2takey/ffmpeg-go v0.5.0 // indirect
 	github.com/u2takey/go-utils v0.3.1 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasthttp v1.51.0 // indirect
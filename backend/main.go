@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -13,21 +16,170 @@ import (
 	"github.com/brandonnguyenn27/ascii-converter/pkg/converter"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"golang.org/x/term"
 )
 
 func main() {
 	// Define all flags
 	serverMode := flag.Bool("server", false, "Start the REST API server")
 	useColor := flag.Bool("color", false, "Enable colored ASCII output")
-	width := flag.Int("width", 100, "Width of ASCII output in characters")
-	palette := flag.String("palette", "normal", "Character palette: normal, dense, sparse, or unicode")
+	colors := flag.String("colors", "", "Color depth for -color output: truecolor, 256, 16, or none (default: detected from COLORTERM, TERM and NO_COLOR)")
+	colorDither := flag.Bool("color-dither", false, "Dither colors when quantizing to 256 or 16 colors")
+	colorTolerance := flag.Float64("color-tolerance", 0, "CIELAB distance within which neighbouring cells share one color escape")
+	width := flag.Int("width", 100, "Width of ASCII output in characters (default: terminal width on a TTY)")
+	height := flag.Int("height", 0, "Height of ASCII output in characters (default: from -width, or terminal height on a TTY)")
+	charAspect := flag.Float64("aspect", 0, "Character width/height ratio of your font (default: 0.5, or the font cell for shape mode)")
+	fit := flag.String("fit", converter.FitContain, "How to fill a -width x -height box: contain, cover, or stretch")
+	crop := flag.String("crop", "", "Region to keep before resizing: x,y,width,height in pixels or percent (e.g. 10%,0%,80%,100%)")
+	rotate := flag.Int("rotate", 0, "Rotate the image clockwise before resizing: 0, 90, 180, or 270")
+	flipH := flag.Bool("flip-h", false, "Mirror the image left to right")
+	flipV := flag.Bool("flip-v", false, "Mirror the image top to bottom")
+	frame := flag.Int("frame", 0, "Frame of an animated GIF to convert, counted from 0")
+	grayModel := flag.String("gray-model", converter.GrayRec601, "How colors become glyph brightness: rec601, rec709, average, max, lightness, red, green, blue, or linear (gamma-correct)")
+	resample := flag.String("resample", converter.ResampleLanczos, "Resampling filter: nearest, bilinear, bicubic, lanczos, or box (area average, best for large photos)")
+	fastGray := flag.Bool("fast-gray", false, "Resize plain ascii output straight to grayscale: much faster on large photos, with slightly different rounding")
+	palette := flag.String("palette", "normal", "Character palette: normal, dense, sparse, unicode, or a name loaded from -palette-dir")
+	paletteChars := flag.String("palette-chars", "", "Inline character ramp ordered dark to bright (overrides -palette)")
+	paletteDir := flag.String("palette-dir", "", "Directory of .json/.toml palette files to register at startup")
+	mode := flag.String("mode", converter.ModeASCII, "Render mode: ascii, braille, edges, shape, or halfblock (requires -color)")
+	threshold := flag.Int("threshold", 128, "Brightness threshold (0-255) for raising Braille dots")
+	dots := flag.String("dots", converter.DotsThreshold, "Braille dot activation: threshold or ordered")
+	dither := flag.String("dither", converter.DitherNone, "Grayscale dithering: none, floyd-steinberg, atkinson, sierra, bayer2, bayer4, or bayer8 (default for -o videos: bayer4)")
+	edgeOperator := flag.String("edge-operator", converter.EdgeSobel, "Edge detection operator for edges mode: sobel or scharr")
+	brightness := flag.Float64("brightness", 0, "Brightness offset, -1 to 1")
+	contrast := flag.Float64("contrast", 0, "Contrast, -1 (flat) to 1 (maximum)")
+	gamma := flag.Float64("gamma", 1, "Midtone gamma; above 1 brightens, below 1 darkens")
+	blackPoint := flag.Int("black-point", 0, "Input level (0-255) mapped to black")
+	whitePoint := flag.Int("white-point", 255, "Input level (0-255) mapped to white")
+	invert := flag.Bool("invert", false, "Invert glyph ordering for light-background terminals")
+	equalize := flag.String("equalize", converter.EqualizeNone, "Automatic contrast for grayscale output: none, global, or clahe")
+	claheTile := flag.Int("clahe-tile", converter.DefaultEqualizeOptions().TileSize, "CLAHE tile size in pixels (after resizing)")
+	claheClip := flag.Float64("clahe-clip", converter.DefaultEqualizeOptions().ClipLimit, "CLAHE clip limit as a multiple of the mean histogram bin height")
+	filters := flag.String("filters", "", "Comma-separated filter chain, e.g. median:1,bilateral:2:30,gaussian:1,sharpen:1:0.8")
+	filterStage := flag.String("filter-stage", converter.FilterStageAfter, "When to run -filters: before or after resizing")
+	alpha := flag.String("alpha", converter.AlphaComposite, "Transparency handling: composite (over -alpha-bg) or cutout (empty cells)")
+	alphaBackground := flag.String("alpha-bg", "#000000", "Background color transparent pixels are composited over")
+	alphaThreshold := flag.Int("alpha-threshold", int(converter.DefaultAlphaOptions().Threshold), "Alpha (0-255) below which a cell is empty in cutout mode")
+	edgeThreshold := flag.Float64("edge-threshold", converter.DefaultEdgeOptions().Threshold, "Gradient magnitude above which edges mode draws a directional glyph")
+	output := flag.String("o", "", "Write the result to a file instead of the terminal: .png or .jpg/.jpeg, or .gif, .mp4 or .webm to convert a video")
+	cellWidth := flag.Int("cell-width", converter.DefaultRasterOptions().CellWidth, "Character cell width in pixels for -o images")
+	cellHeight := flag.Int("cell-height", converter.DefaultRasterOptions().CellHeight, "Character cell height in pixels for -o images")
+	foreground := flag.String("fg", "#ffffff", "Text color of grayscale output in -o images")
+	background := flag.String("bg", "#000000", "Background color of -o images")
+	padding := flag.Int("padding", converter.DefaultRasterOptions().Padding, "Margin around the art in pixels for -o images")
+	quality := flag.Int("quality", converter.DefaultRasterOptions().Quality, "JPEG quality (1-100) for -o images")
+	fps := flag.Int("fps", defaultVideoFps, "Frames sampled per second of video for -o .gif, .mp4 or .webm (1-15)")
+	gifColors := flag.Int("gif-colors", converter.DefaultGIFOptions().Colors, "Palette size (2-256) of -o .gif animations")
+	crf := flag.Int("crf", 0, "Constant rate factor of -o .mp4 (0-51) or .webm (0-63) videos; lower is better (default: the codec's own, 23 or 31)")
+	audio := flag.Bool("audio", false, "Copy the audio track of the input video into -o .mp4 or .webm videos")
 
 	flag.Parse()
 
+	// Register user-defined palettes before they can be referenced
+	if *paletteDir != "" {
+		if err := converter.DefaultPalettes.LoadDir(*paletteDir); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
 	if *serverMode {
 		startServer()
 	} else {
-		runCLI(*useColor, *width, *palette)
+		filterChain, err := converter.ParseFilterChain(*filters)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		alphaBg, err := converter.ParseHexColor(*alphaBackground)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		cropRect, err := converter.ParseCrop(*crop)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fg, err := converter.ParseHexColor(*foreground)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		bg, err := converter.ParseHexColor(*background)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		opts := converter.Options{
+			Width:        *width,
+			Height:       *height,
+			CharAspect:   *charAspect,
+			Fit:          *fit,
+			Resample:     *resample,
+			FastGray:     *fastGray,
+			GrayModel:    *grayModel,
+			Palette:      *palette,
+			PaletteChars: *paletteChars,
+			Mode:         *mode,
+			Color:        *useColor,
+			Dither:       *dither,
+			Transform:    converter.TransformOptions{Crop: cropRect, Rotate: *rotate, FlipH: *flipH, FlipV: *flipV},
+			Braille:      converter.BrailleOptions{Threshold: clampUint8(*threshold), Dots: *dots},
+			Edge:         converter.EdgeOptions{Operator: *edgeOperator, Threshold: *edgeThreshold},
+			Adjust: converter.Adjustments{
+				Brightness: *brightness,
+				Contrast:   *contrast,
+				Gamma:      *gamma,
+				BlackPoint: clampUint8(*blackPoint),
+				WhitePoint: clampUint8(*whitePoint),
+				Invert:     *invert,
+			},
+			Equalize: converter.EqualizeOptions{Method: *equalize, TileSize: *claheTile, ClipLimit: *claheClip},
+			Filters:  converter.FilterChain{Filters: filterChain, Stage: *filterStage},
+			Alpha:    converter.AlphaOptions{Mode: *alpha, Background: alphaBg, Threshold: clampUint8(*alphaThreshold)},
+		}
+		// Flags given explicitly on the command line
+		setFlags := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+		// A height alone derives the width from the image
+		if setFlags["height"] && !setFlags["width"] {
+			opts.Width = 0
+		}
+		// Fill the terminal when printing to one and no size was requested
+		if !setFlags["width"] && !setFlags["height"] && *output == "" {
+			if cols, rows, err := term.GetSize(int(os.Stdout.Fd())); err == nil && cols > 0 && rows > 1 {
+				// Leave a line for the shell prompt
+				opts.Width, opts.Height = cols, rows-1
+			}
+		}
+		// Videos default to ordered dithering like the server, since error diffusion
+		// patterns shimmer between frames
+		if !setFlags["dither"] && videoOutputFormat(*output) != "" {
+			opts.Dither = converter.DitherBayer4
+		}
+
+		ansi := converter.ANSIOptions{Colors: *colors, Dither: *colorDither, Tolerance: *colorTolerance}
+		if ansi.Colors == "" {
+			ansi.Colors = converter.DetectColorDepth()
+		}
+		out := outputOptions{
+			Path: *output,
+			Raster: converter.RasterOptions{
+				CellWidth:  *cellWidth,
+				CellHeight: *cellHeight,
+				Foreground: fg,
+				Background: bg,
+				Padding:    *padding,
+				Quality:    *quality,
+			},
+			Fps:       *fps,
+			GIFColors: *gifColors,
+			CRF:       *crf,
+			Audio:     *audio,
+		}
+		runCLI(opts, ansi, *frame, out)
 	}
 }
 
@@ -39,7 +191,7 @@ func startServer() {
 	// Configure CORS middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "http://localhost:5173",
-		AllowMethods: "POST,OPTIONS",
+		AllowMethods: "GET,POST,OPTIONS",
 		AllowHeaders: "Content-Type",
 	}))
 
@@ -47,6 +199,14 @@ func startServer() {
 	app.Post("/convert/color", convertColorHandler) // Colored ASCII (returns structured data)
 	app.Post("/convert/video", convertVideoHandler) // Video to ASCII (returns frames array)
 	app.Post("/export/svg", exportSVGHandler)       // Export ASCII as SVG
+	app.Post("/export/ansi", exportANSIHandler)     // Export colored ASCII as ANSI text
+	app.Post("/export/html", exportHTMLHandler)     // Export ASCII as HTML
+	app.Post("/export/png", exportPNGHandler)       // Export ASCII as a PNG image
+	app.Post("/export/jpeg", exportJPEGHandler)     // Export ASCII as a JPEG image
+	app.Post("/export/video/gif", videoGIFHandler)  // Export video ASCII as an animated GIF
+	app.Post("/export/video", exportVideoHandler)   // Export video ASCII as MP4 or WebM
+	app.Get("/palettes", palettesHandler)           // List registered palettes
+	app.Get("/formats", formatsHandler)             // List supported image formats
 
 	log.Println("Server starting on :3000")
 	log.Fatal(app.Listen(":3000"))
@@ -64,25 +224,30 @@ func convertHandler(c *fiber.Ctx) error {
 	// Get file size
 	fileSize := file.Size
 
-	// Get optional width parameter (default: 100)
-	width := 100
-	if widthStr := c.FormValue("width"); widthStr != "" {
-		if parsedWidth, err := strconv.Atoi(widthStr); err == nil && parsedWidth > 0 {
-			width = parsedWidth
-		}
-	} else if widthStr := c.Query("width"); widthStr != "" {
-		if parsedWidth, err := strconv.Atoi(widthStr); err == nil && parsedWidth > 0 {
-			width = parsedWidth
-		}
+	// Get conversion options: width, palette, render mode and its settings (default: ascii, no dithering)
+	opts, err := optionsFromRequest(c, converter.DitherNone)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-
-	// Get optional palette parameter (default: normal)
-	palette := c.FormValue("palette")
-	if palette == "" {
-		palette = c.Query("palette")
+	if converter.ModeRequiresColor(opts.Mode) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Mode '%s' requires color output. Use /convert/color instead.", opts.Mode),
+		})
+	}
+	if err := opts.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-	if palette == "" {
-		palette = converter.PaletteNormal
+
+	// Get optional animated GIF frame (default: 0, the first frame)
+	frame, err := frameFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
 	// Open the uploaded file
@@ -94,25 +259,22 @@ func convertHandler(c *fiber.Ctx) error {
 	}
 	defer fileHeader.Close()
 
-	// Load the image from the reader
-	img, err := converter.LoadImageFromReader(fileHeader)
+	// Load the image (or the requested frame of an animated GIF) from the reader
+	img, imageFormat, err := converter.LoadImageFrameFromReader(fileHeader, frame)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	// Get original dimensions
-	originalBounds := img.Bounds()
-	originalWidth := originalBounds.Max.X - originalBounds.Min.X
-	originalHeight := originalBounds.Max.Y - originalBounds.Min.Y
-
-	// Resize the image
-	resizedImg := converter.ResizeImage(img, width)
-
-	// Convert to grayscale ASCII
-	grayScaleImg := converter.ConvertToGrayscale(resizedImg)
-	asciiImg := converter.ConvertToASCII(grayScaleImg, palette)
+	// Resize and convert to grayscale text in the requested mode
+	result, err := converter.Convert(img, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	asciiImg := result.Text
 
 	// Calculate ASCII size in bytes
 	// len() returns byte length, which correctly accounts for:
@@ -124,8 +286,9 @@ func convertHandler(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"ascii":          asciiImg,
 		"originalSize":   fileSize,
-		"originalWidth":  originalWidth,
-		"originalHeight": originalHeight,
+		"originalWidth":  result.OriginalWidth,
+		"originalHeight": result.OriginalHeight,
+		"imageFormat":    imageFormat,
 		"asciiSize":      asciiSize,
 	})
 }
@@ -142,25 +305,34 @@ func convertColorHandler(c *fiber.Ctx) error {
 	// Get file size
 	fileSize := file.Size
 
-	// Get optional width parameter (default: 100)
-	width := 100
-	if widthStr := c.FormValue("width"); widthStr != "" {
-		if parsedWidth, err := strconv.Atoi(widthStr); err == nil && parsedWidth > 0 {
-			width = parsedWidth
-		}
-	} else if widthStr := c.Query("width"); widthStr != "" {
-		if parsedWidth, err := strconv.Atoi(widthStr); err == nil && parsedWidth > 0 {
-			width = parsedWidth
-		}
+	// Get conversion options: width, palette, render mode and its settings (default: ascii, no dithering)
+	opts, err := optionsFromRequest(c, converter.DitherNone)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	opts.Color = true
+	if err := opts.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	// Get optional palette parameter (default: normal)
-	palette := c.FormValue("palette")
-	if palette == "" {
-		palette = c.Query("palette")
+	// Get optional wire format (default: full)
+	format := formOrQuery(c, "format")
+	if err := converter.ValidateFormat(format); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-	if palette == "" {
-		palette = converter.PaletteNormal
+
+	// Get optional animated GIF frame (default: 0, the first frame)
+	frame, err := frameFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
 	// Open the uploaded file
@@ -172,24 +344,38 @@ func convertColorHandler(c *fiber.Ctx) error {
 	}
 	defer fileHeader.Close()
 
-	// Load the image from the reader
-	img, err := converter.LoadImageFromReader(fileHeader)
+	// Load the image (or the requested frame of an animated GIF) from the reader
+	img, imageFormat, err := converter.LoadImageFrameFromReader(fileHeader, frame)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	// Get original dimensions
-	originalBounds := img.Bounds()
-	originalWidth := originalBounds.Max.X - originalBounds.Min.X
-	originalHeight := originalBounds.Max.Y - originalBounds.Min.Y
-
-	// Resize the image
-	resizedImg := converter.ResizeImage(img, width)
-
-	// Convert to colored ASCII with structured data
-	coloredASCII := converter.ConvertToASCIIWithColorStructured(resizedImg, palette)
+	// Resize and convert to colored ASCII with structured data
+	result, err := converter.Convert(img, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	coloredASCII := *result.Colored
+
+	// Compact format: per-line color runs indexing a shared color table
+	if format == converter.FormatCompact {
+		compact := coloredASCII.Compact()
+		jsonBytes, _ := json.Marshal(compact)
+		return c.JSON(fiber.Map{
+			"format":         converter.FormatCompact,
+			"colors":         compact.Colors,
+			"lines":          compact.Lines,
+			"originalSize":   fileSize,
+			"originalWidth":  result.OriginalWidth,
+			"originalHeight": result.OriginalHeight,
+			"imageFormat":    imageFormat,
+			"asciiSize":      len(jsonBytes),
+		})
+	}
 
 	// Calculate ASCII size by converting to JSON and measuring byte length
 	// This accounts for the actual JSON representation size, which includes:
@@ -202,8 +388,9 @@ func convertColorHandler(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"lines":          coloredASCII.Lines,
 		"originalSize":   fileSize,
-		"originalWidth":  originalWidth,
-		"originalHeight": originalHeight,
+		"originalWidth":  result.OriginalWidth,
+		"originalHeight": result.OriginalHeight,
+		"imageFormat":    imageFormat,
 		"asciiSize":      asciiSize,
 	})
 }
@@ -217,29 +404,41 @@ func exportSVGHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get optional width parameter (default: 100)
-	width := 100
-	if widthStr := c.FormValue("width"); widthStr != "" {
-		if parsedWidth, err := strconv.Atoi(widthStr); err == nil && parsedWidth > 0 {
-			width = parsedWidth
-		}
+	// Get conversion options: width, palette, render mode and its settings (default: ascii, no dithering)
+	opts, err := optionsFromRequest(c, converter.DitherNone)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	// Get optional palette parameter (default: normal)
-	palette := c.FormValue("palette")
-	if palette == "" {
-		palette = converter.PaletteNormal
+	// Get optional color mode
+	opts.Color = c.FormValue("color") == "true" || c.Query("color") == "true"
+	if converter.ModeRequiresColor(opts.Mode) && !opts.Color {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Mode '%s' requires color=true", opts.Mode),
+		})
+	}
+	if err := opts.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	// Get optional color mode
-	useColor := c.FormValue("color") == "true" || c.Query("color") == "true"
+	// Get optional font, spacing and background settings (default: white 12px monospace on black)
+	svgOpts, err := svgFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	// Get optional fontSize (default: 12)
-	fontSize := 12
-	if fontSizeStr := c.FormValue("fontSize"); fontSizeStr != "" {
-		if parsedSize, err := strconv.Atoi(fontSizeStr); err == nil && parsedSize > 0 {
-			fontSize = parsedSize
-		}
+	// Get optional animated GIF frame (default: 0, the first frame)
+	frame, err := frameFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
 	// Open the uploaded file
@@ -251,26 +450,21 @@ func exportSVGHandler(c *fiber.Ctx) error {
 	}
 	defer fileHeader.Close()
 
-	// Load the image from the reader
-	img, err := converter.LoadImageFromReader(fileHeader)
+	// Load the image (or the requested frame of an animated GIF) from the reader
+	img, _, err := converter.LoadImageFrameFromReader(fileHeader, frame)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	// Resize the image
-	resizedImg := converter.ResizeImage(img, width)
-
-	var svg string
-	if useColor {
-		coloredASCII := converter.ConvertToASCIIWithColorStructured(resizedImg, palette)
-		svg = converter.ConvertToSVG("", &coloredASCII, fontSize)
-	} else {
-		grayScaleImg := converter.ConvertToGrayscale(resizedImg)
-		asciiImg := converter.ConvertToASCII(grayScaleImg, palette)
-		svg = converter.ConvertToSVG(asciiImg, nil, fontSize)
+	result, err := converter.Convert(img, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
+	svg := result.SVG(svgOpts)
 
 	// Generate filename from original file
 	filename := generateExportFilename(file.Filename, "_svg")
@@ -280,50 +474,56 @@ func exportSVGHandler(c *fiber.Ctx) error {
 	return c.SendString(svg)
 }
 
-func convertVideoHandler(c *fiber.Ctx) error {
-	// Get the uploaded video file
-	file, err := c.FormFile("video")
+func exportANSIHandler(c *fiber.Ctx) error {
+	// Get the uploaded file
+	file, err := c.FormFile("image")
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Missing or invalid video file. Please upload a video using the 'video' field.",
+			"error": "Missing or invalid image file. Please upload an image using the 'image' field.",
 		})
 	}
 
-	// Get file size
-	fileSize := file.Size
-
-	// Validate file size (max 50MB)
-	const maxFileSize = 50 * 1024 * 1024 // 50MB
-	if fileSize > maxFileSize {
+	// Get conversion options: width, palette, render mode and its settings (default: ascii, no dithering)
+	opts, err := optionsFromRequest(c, converter.DitherNone)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": fmt.Sprintf("Video file too large. Maximum size is %d MB.", maxFileSize/(1024*1024)),
+			"error": err.Error(),
 		})
 	}
-
-	// Get optional width parameter (default: 100)
-	width := 100
-	if widthStr := c.FormValue("width"); widthStr != "" {
-		if parsedWidth, err := strconv.Atoi(widthStr); err == nil && parsedWidth > 0 {
-			width = parsedWidth
-		}
+	opts.Color = true
+	if err := opts.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	// Get optional palette parameter (default: normal)
-	palette := c.FormValue("palette")
-	if palette == "" {
-		palette = converter.PaletteNormal
+	// Get optional color depth (default: truecolor), color dithering and color merge tolerance
+	ansi := converter.ANSIOptions{
+		Colors: formOrQuery(c, "colors"),
+		Dither: formOrQuery(c, "colorDither") == "true",
 	}
-
-	// Get optional fps parameter (default: 10)
-	fps := 10
-	if fpsStr := c.FormValue("fps"); fpsStr != "" {
-		if parsedFps, err := strconv.Atoi(fpsStr); err == nil && parsedFps > 0 && parsedFps <= 15 {
-			fps = parsedFps
+	if toleranceStr := formOrQuery(c, "colorTolerance"); toleranceStr != "" {
+		parsed, err := strconv.ParseFloat(toleranceStr, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("invalid colorTolerance %q", toleranceStr),
+			})
 		}
+		ansi.Tolerance = parsed
+	}
+	if err := ansi.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	// Get optional color mode (default: false)
-	useColor := c.FormValue("color") == "true"
+	// Get optional animated GIF frame (default: 0, the first frame)
+	frame, err := frameFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	// Open the uploaded file
 	fileHeader, err := file.Open()
@@ -334,123 +534,763 @@ func convertVideoHandler(c *fiber.Ctx) error {
 	}
 	defer fileHeader.Close()
 
-	// Extract frames from video
-	frames, metadata, err := converter.ExtractFramesFromVideo(fileHeader, fps, int(fileSize))
+	// Load the image (or the requested frame of an animated GIF) from the reader
+	img, _, err := converter.LoadImageFrameFromReader(fileHeader, frame)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": fmt.Sprintf("Failed to extract frames: %v", err),
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
-	// Get first frame dimensions for metadata
-	if len(frames) > 0 {
-		firstFrame := frames[0]
-		bounds := firstFrame.Bounds()
-		metadata.Width = bounds.Max.X - bounds.Min.X
-		metadata.Height = bounds.Max.Y - bounds.Min.Y
+	result, err := converter.Convert(img, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	// Convert frames to ASCII
-	if useColor {
-		// Color mode
-		colorFrames, err := converter.ProcessVideoToColorASCII(frames, width, palette)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": fmt.Sprintf("Failed to convert frames: %v", err),
-			})
-		}
+	// Generate filename from original file, with the .ans extension used for ANSI art
+	filename := generateExportFilename(file.Filename, "_ansi")
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".ans"
 
-		// Update timestamps based on actual FPS
-		for i := range colorFrames {
-			colorFrames[i].Timestamp = float64(i) / float64(fps)
-		}
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.SendString(result.ANSI(ansi))
+}
 
-		return c.JSON(converter.VideoColorAsciiResult{
-			Frames:   colorFrames,
-			Metadata: *metadata,
+func exportHTMLHandler(c *fiber.Ctx) error {
+	// Get the uploaded file
+	file, err := c.FormFile("image")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing or invalid image file. Please upload an image using the 'image' field.",
 		})
-	} else {
-		// Grayscale mode
-		asciiFrames, err := converter.ProcessVideoToASCII(frames, width, palette)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": fmt.Sprintf("Failed to convert frames: %v", err),
-			})
-		}
-
-		// Update timestamps based on actual FPS
-		for i := range asciiFrames {
-			asciiFrames[i].Timestamp = float64(i) / float64(fps)
-		}
+	}
 
-		return c.JSON(converter.VideoAsciiResult{
-			Frames:   asciiFrames,
-			Metadata: *metadata,
+	// Get conversion options: width, palette, render mode and its settings (default: ascii, no dithering)
+	opts, err := optionsFromRequest(c, converter.DitherNone)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
-}
-
-// generateExportFilename creates a filename by appending suffix before the extension
-func generateExportFilename(originalFilename, suffix string) string {
-	// Remove path if present, get just the filename
-	filename := filepath.Base(originalFilename)
 
-	// Get extension
-	ext := filepath.Ext(filename)
+	// Get optional color mode
+	opts.Color = formOrQuery(c, "color") == "true"
+	if converter.ModeRequiresColor(opts.Mode) && !opts.Color {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Mode '%s' requires color=true", opts.Mode),
+		})
+	}
+	if err := opts.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	// Remove extension
-	nameWithoutExt := strings.TrimSuffix(filename, ext)
+	// Get optional page styling (default: white 12px monospace on black, whole document)
+	htmlOpts, err := htmlFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	// Append suffix and extension
-	return nameWithoutExt + suffix + ext
-}
+	// Get optional animated GIF frame (default: 0, the first frame)
+	frame, err := frameFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-func runCLI(useColor bool, width int, palette string) {
-	// Check if user provided an image path (after flags)
-	if flag.NArg() < 1 {
-		fmt.Println("Usage: go run main.go [flags] <image-path>")
-		fmt.Println("       go run main.go --server  (to start API server)")
-		fmt.Println("\nFlags:")
-		flag.PrintDefaults()
-		fmt.Println("\nExample: go run main.go -color -width 120 -palette dense images/apple.png")
-		fmt.Println("         go run main.go --server")
-		os.Exit(1)
+	// Open the uploaded file
+	fileHeader, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to open uploaded file",
+		})
 	}
+	defer fileHeader.Close()
 
-	// Validate palette
-	validPalettes := map[string]bool{
-		converter.PaletteNormal:  true,
-		converter.PaletteDense:   true,
-		converter.PaletteSparse:  true,
-		converter.PaletteUnicode: true,
+	// Load the image (or the requested frame of an animated GIF) from the reader
+	img, _, err := converter.LoadImageFrameFromReader(fileHeader, frame)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-	if !validPalettes[palette] {
-		fmt.Printf("Error: Invalid palette '%s'. Valid options: normal, dense, sparse, unicode\n", palette)
-		os.Exit(1)
+
+	result, err := converter.Convert(img, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	// Get the image path (first non-flag argument)
-	imagePath := flag.Arg(0)
+	// Generate filename from original file, with the .html extension
+	filename := generateExportFilename(file.Filename, "_html")
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".html"
+
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.SendString(result.HTML(htmlOpts))
+}
+
+func exportPNGHandler(c *fiber.Ctx) error {
+	return exportImage(c, converter.RasterPNG)
+}
 
-	// Load the image
-	img, err := converter.LoadImage(imagePath)
+func exportJPEGHandler(c *fiber.Ctx) error {
+	return exportImage(c, converter.RasterJPEG)
+}
+
+// exportImage draws the ASCII art with the embedded bitmap font and sends it as an image in
+// format (converter.RasterPNG or converter.RasterJPEG)
+func exportImage(c *fiber.Ctx, format string) error {
+	// Get the uploaded file
+	file, err := c.FormFile("image")
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing or invalid image file. Please upload an image using the 'image' field.",
+		})
 	}
 
-	// Resize the image
-	resizedImg := converter.ResizeImage(img, width)
-
-	// Convert to ASCII (color or grayscale)
-	var asciiImg string
-	if useColor {
-		asciiImg = converter.ConvertToASCIIWithColor(resizedImg, palette)
-	} else {
-		grayScaleImg := converter.ConvertToGrayscale(resizedImg)
-		asciiImg = converter.ConvertToASCII(grayScaleImg, palette)
+	// Get conversion options: width, palette, render mode and its settings (default: ascii, no dithering)
+	opts, err := optionsFromRequest(c, converter.DitherNone)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	// Output the ASCII art
-	fmt.Println(asciiImg)
+	// Get optional color mode
+	opts.Color = formOrQuery(c, "color") == "true"
+	if converter.ModeRequiresColor(opts.Mode) && !opts.Color {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Mode '%s' requires color=true", opts.Mode),
+		})
+	}
+	if err := opts.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Get optional drawing options (default: white 7x13 glyphs on black)
+	raster, err := rasterFromRequest(c, format)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Get optional animated GIF frame (default: 0, the first frame)
+	frame, err := frameFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Open the uploaded file
+	fileHeader, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to open uploaded file",
+		})
+	}
+	defer fileHeader.Close()
+
+	// Load the image (or the requested frame of an animated GIF) from the reader
+	img, _, err := converter.LoadImageFrameFromReader(fileHeader, frame)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := converter.Convert(img, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var encoded bytes.Buffer
+	if err := result.WriteImage(&encoded, raster); err != nil {
+		return c.Status(exportErrorStatus(err)).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to encode image: %v", err),
+		})
+	}
+
+	// Generate filename from original file, with the extension of the exported format
+	ext := ".png"
+	if format == converter.RasterJPEG {
+		ext = ".jpg"
+	}
+	filename := generateExportFilename(file.Filename, "_"+format)
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ext
+
+	c.Set("Content-Type", "image/"+format)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.Send(encoded.Bytes())
+}
+
+// Limits of uploaded videos and of the frame rate they are sampled at
+const (
+	maxVideoSize    = 50 * 1024 * 1024 // 50MB
+	defaultVideoFps = 10
+	maxVideoFps     = 15
+)
+
+func convertVideoHandler(c *fiber.Ctx) error {
+	// Get the uploaded video file
+	file, err := c.FormFile("video")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing or invalid video file. Please upload a video using the 'video' field.",
+		})
+	}
+
+	// Get file size
+	fileSize := file.Size
+
+	// Validate file size (max 50MB)
+	if fileSize > maxVideoSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Video file too large. Maximum size is %d MB.", maxVideoSize/(1024*1024)),
+		})
+	}
+
+	// Get conversion options: width, palette, tone, contrast and filter settings
+	// (default dithering: bayer4, since error diffusion shimmers between frames)
+	opts, err := optionsFromRequest(c, converter.DitherBayer4)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := converter.ValidateVideoMode(opts.Mode); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Get optional fps parameter (default: 10)
+	fps := fpsFromRequest(c)
+
+	// Get optional color mode (default: false) and wire format for color frames (default: full)
+	useColor := c.FormValue("color") == "true"
+	format := formOrQuery(c, "format")
+	if err := converter.ValidateFormat(format); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Open the uploaded file
+	fileHeader, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to open uploaded file",
+		})
+	}
+	defer fileHeader.Close()
+
+	gray, colored, err := convertVideo(fileHeader, int(fileSize), fps, opts, useColor)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if colored != nil {
+		if format == converter.FormatCompact {
+			return c.JSON(colored.Compact())
+		}
+		return c.JSON(colored)
+	}
+	return c.JSON(gray)
+}
+
+// convertVideo extracts frames from a video at fps and converts them to ASCII, in color when
+// useColor is set. Only the result for the requested mode is returned.
+func convertVideo(r io.Reader, size, fps int, opts converter.Options, useColor bool) (*converter.VideoAsciiResult, *converter.VideoColorAsciiResult, error) {
+	// Extract frames from video
+	frames, metadata, err := converter.ExtractFramesFromVideo(r, fps, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract frames: %w", err)
+	}
+
+	// Get first frame dimensions for metadata
+	if len(frames) > 0 {
+		bounds := frames[0].Bounds()
+		metadata.Width = bounds.Dx()
+		metadata.Height = bounds.Dy()
+	}
+
+	if useColor {
+		colorFrames, err := converter.ProcessVideoToColorASCII(frames, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to convert frames: %w", err)
+		}
+		// Update timestamps based on actual FPS
+		for i := range colorFrames {
+			colorFrames[i].Timestamp = float64(i) / float64(fps)
+		}
+		return nil, &converter.VideoColorAsciiResult{Frames: colorFrames, Metadata: *metadata}, nil
+	}
+
+	asciiFrames, err := converter.ProcessVideoToASCII(frames, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert frames: %w", err)
+	}
+	// Update timestamps based on actual FPS
+	for i := range asciiFrames {
+		asciiFrames[i].Timestamp = float64(i) / float64(fps)
+	}
+	return &converter.VideoAsciiResult{Frames: asciiFrames, Metadata: *metadata}, nil, nil
+}
+
+// videoGIFHandler converts an uploaded video and sends it as an animated GIF drawn with the
+// embedded bitmap font, playing at the sampled frame rate
+func videoGIFHandler(c *fiber.Ctx) error {
+	// Get the uploaded video file
+	file, err := c.FormFile("video")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing or invalid video file. Please upload a video using the 'video' field.",
+		})
+	}
+
+	// Validate file size (max 50MB)
+	if file.Size > maxVideoSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Video file too large. Maximum size is %d MB.", maxVideoSize/(1024*1024)),
+		})
+	}
+
+	// Get conversion options, as for /convert/video (default dithering: bayer4)
+	opts, err := optionsFromRequest(c, converter.DitherBayer4)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := converter.ValidateVideoMode(opts.Mode); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Get optional fps (default: 10) and color mode (default: false)
+	fps := fpsFromRequest(c)
+	useColor := formOrQuery(c, "color") == "true"
+
+	// Get optional drawing options (default: white 7x13 glyphs on black, 256 colors)
+	gifOpts, err := gifFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Open the uploaded file
+	fileHeader, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to open uploaded file",
+		})
+	}
+	defer fileHeader.Close()
+
+	gray, colored, err := convertVideo(fileHeader, int(file.Size), fps, opts, useColor)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var encoded bytes.Buffer
+	if colored != nil {
+		err = colored.WriteGIF(&encoded, gifOpts)
+	} else {
+		err = gray.WriteGIF(&encoded, gifOpts)
+	}
+	if err != nil {
+		return c.Status(exportErrorStatus(err)).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to encode GIF: %v", err),
+		})
+	}
+
+	// Generate filename from original file, with the .gif extension
+	filename := generateExportFilename(file.Filename, "_ascii")
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".gif"
+
+	c.Set("Content-Type", "image/gif")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.Send(encoded.Bytes())
+}
+
+// exportVideoHandler converts an uploaded video and re-encodes the ASCII frames, drawn with
+// the embedded bitmap font, as an MP4 or WebM video at the sampled frame rate
+func exportVideoHandler(c *fiber.Ctx) error {
+	// Get the uploaded video file
+	file, err := c.FormFile("video")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing or invalid video file. Please upload a video using the 'video' field.",
+		})
+	}
+
+	// Validate file size (max 50MB)
+	if file.Size > maxVideoSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Video file too large. Maximum size is %d MB.", maxVideoSize/(1024*1024)),
+		})
+	}
+
+	// Get conversion options, as for /convert/video (default dithering: bayer4)
+	opts, err := optionsFromRequest(c, converter.DitherBayer4)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := converter.ValidateVideoMode(opts.Mode); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Get optional fps (default: 10) and color mode (default: false)
+	fps := fpsFromRequest(c)
+	useColor := formOrQuery(c, "color") == "true"
+
+	// Get optional encoding options (default: white 7x13 glyphs on black, H.264 MP4, no audio)
+	encodeOpts, useAudio, err := videoEncodeFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Keep the upload on disk, so FFmpeg can read its audio track
+	source, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to store uploaded file",
+		})
+	}
+	source.Close()
+	defer os.Remove(source.Name())
+	if err := c.SaveFile(file, source.Name()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to store uploaded file",
+		})
+	}
+	if useAudio {
+		encodeOpts.Audio = source.Name()
+	}
+
+	// Open the stored file
+	fileHeader, err := os.Open(source.Name())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to open uploaded file",
+		})
+	}
+	defer fileHeader.Close()
+
+	gray, colored, err := convertVideo(fileHeader, int(file.Size), fps, opts, useColor)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Encode into a temporary file (MP4 cannot be written as a stream)
+	output, err := os.CreateTemp("", "export-*."+encodeOpts.Format)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create output file",
+		})
+	}
+	output.Close()
+	defer os.Remove(output.Name())
+	if colored != nil {
+		err = colored.WriteVideo(output.Name(), encodeOpts)
+	} else {
+		err = gray.WriteVideo(output.Name(), encodeOpts)
+	}
+	if err != nil {
+		return c.Status(exportErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	encoded, err := os.ReadFile(output.Name())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read encoded video",
+		})
+	}
+
+	// Generate filename from original file, with the extension of the container
+	filename := generateExportFilename(file.Filename, "_ascii")
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + "." + encodeOpts.Format
+
+	c.Set("Content-Type", "video/"+encodeOpts.Format)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.Send(encoded)
+}
+
+// formOrQuery returns the named form field, falling back to the query param of the same name
+func formOrQuery(c *fiber.Ctx, key string) string {
+	if value := c.FormValue(key); value != "" {
+		return value
+	}
+	return c.Query(key)
+}
+
+func palettesHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"palettes": converter.DefaultPalettes.List(),
+	})
+}
+
+func formatsHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"formats": converter.SupportedFormats(),
+	})
+}
+
+// exportErrorStatus returns the status for a failed export: art too large to draw is a bad
+// request, anything else a server error
+func exportErrorStatus(err error) int {
+	if errors.Is(err, converter.ErrRasterTooLarge) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
+
+// generateExportFilename creates a filename by appending suffix before the extension
+func generateExportFilename(originalFilename, suffix string) string {
+	// Remove path if present, get just the filename
+	filename := filepath.Base(originalFilename)
+
+	// Get extension
+	ext := filepath.Ext(filename)
+
+	// Remove extension
+	nameWithoutExt := strings.TrimSuffix(filename, ext)
+
+	// Append suffix and extension
+	return nameWithoutExt + suffix + ext
+}
+
+// outputOptions selects where runCLI writes the result
+type outputOptions struct {
+	Path   string                  // File to write instead of the terminal (-o); its extension picks the format
+	Raster converter.RasterOptions // Drawing options for image files
+
+	// Video input, converted when the output is an animation
+	Fps       int  // Frames sampled per second
+	GIFColors int  // Palette size of GIF files
+	CRF       int  // Quality of MP4 and WebM files (0: the codec's default)
+	Audio     bool // Copy the input's audio track into MP4 and WebM files
+}
+
+// videoOutputFormat returns "gif", converter.VideoMP4 or converter.VideoWebM when an output
+// file name asks for a video to be converted, or "" otherwise
+func videoOutputFormat(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".gif", ".mp4", ".webm":
+		return strings.TrimPrefix(ext, ".")
+	default:
+		return ""
+	}
+}
+
+// rasterFormat returns the image format for an output file name
+func rasterFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return converter.RasterPNG, nil
+	case ".jpg", ".jpeg":
+		return converter.RasterJPEG, nil
+	default:
+		return "", fmt.Errorf("unsupported output file %q (use .png, .jpg, .jpeg, .gif, .mp4 or .webm)", path)
+	}
+}
+
+func runCLI(opts converter.Options, ansi converter.ANSIOptions, frame int, out outputOptions) {
+	// Check if user provided an image path (after flags)
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: go run main.go [flags] <image-path>")
+		fmt.Println("       go run main.go --server  (to start API server)")
+		fmt.Println("\nFlags:")
+		flag.PrintDefaults()
+		var formats []string
+		for _, format := range converter.SupportedFormats() {
+			formats = append(formats, format.Name)
+		}
+		fmt.Printf("\nSupported image formats: %s (-frame picks a frame of an animated GIF)\n", strings.Join(formats, ", "))
+		fmt.Println("Videos are converted with -o clip.gif, clip.mp4 or clip.webm")
+		fmt.Println("\nExample: go run main.go -color -width 120 -palette dense images/apple.png")
+		fmt.Println("         go run main.go --server")
+		os.Exit(1)
+	}
+
+	// Validate palette, render mode and its settings
+	if converter.ModeRequiresColor(opts.Mode) && !opts.Color {
+		fmt.Printf("Error: mode '%s' requires -color\n", opts.Mode)
+		os.Exit(1)
+	}
+	if err := opts.Validate(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := ansi.Validate(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if out.Path != "" && videoOutputFormat(out.Path) != "" {
+		runVideoCLI(flag.Arg(0), opts, out)
+		return
+	}
+	if out.Path != "" {
+		format, err := rasterFormat(out.Path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		out.Raster.Format = format
+		if err := out.Raster.Validate(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Get the image path (first non-flag argument)
+	imagePath := flag.Arg(0)
+
+	// Load the image (or the requested frame of an animated GIF)
+	img, _, err := converter.LoadImageFrame(imagePath, frame)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Resize and convert to ASCII (color or grayscale) in the requested mode
+	result, err := converter.Convert(img, opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Draw the ASCII art into an image file
+	if out.Path != "" {
+		if err := writeImageFile(out.Path, result, out.Raster); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved %s\n", out.Path)
+		return
+	}
+
+	// Stream the ASCII art, with colors at the terminal's color depth
+	if err := result.WriteANSI(os.Stdout, ansi); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println()
+}
+
+// runVideoCLI converts the video at path frame by frame and writes it to out.Path as an
+// animated GIF, or re-encoded as MP4 or WebM
+func runVideoCLI(path string, opts converter.Options, out outputOptions) {
+	if err := converter.ValidateVideoMode(opts.Mode); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if out.Fps <= 0 || out.Fps > maxVideoFps {
+		fmt.Printf("Error: invalid -fps %d (expected 1-%d)\n", out.Fps, maxVideoFps)
+		os.Exit(1)
+	}
+	format := videoOutputFormat(out.Path)
+	gifOpts := converter.GIFOptions{Raster: out.Raster, Colors: out.GIFColors}
+	encodeOpts := converter.VideoEncodeOptions{Raster: out.Raster, Format: format, CRF: out.CRF}
+	if out.Audio {
+		encodeOpts.Audio = path
+	}
+	validate := encodeOpts.Validate
+	if format == "gif" {
+		validate = gifOpts.Validate
+	}
+	if err := validate(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	gray, colored, err := convertVideo(file, int(info.Size()), out.Fps, opts, opts.Color)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if format == "gif" {
+		err = writeGIFFile(out.Path, gray, colored, gifOpts)
+	} else if colored != nil {
+		err = colored.WriteVideo(out.Path, encodeOpts)
+	} else {
+		err = gray.WriteVideo(out.Path, encodeOpts)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved %s\n", out.Path)
+}
+
+// writeGIFFile writes the converted frames of gray or colored into an animated GIF at path
+func writeGIFFile(path string, gray *converter.VideoAsciiResult, colored *converter.VideoColorAsciiResult, opts converter.GIFOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if colored != nil {
+		err = colored.WriteGIF(file, opts)
+	} else {
+		err = gray.WriteGIF(file, opts)
+	}
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write GIF: %w", err)
+	}
+	return file.Close()
+}
+
+// writeImageFile draws result into a PNG or JPEG file at path
+func writeImageFile(path string, result converter.Result, raster converter.RasterOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := result.WriteImage(file, raster); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+	return file.Close()
 }
@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Adjustments is a tone adjustment stage run between ResizeImage and the mappers.
+// The zero value leaves the image unchanged.
+type Adjustments struct {
+	Brightness float64 // Offset added to every channel, -1 to 1 (0 = unchanged)
+	Contrast   float64 // Contrast around mid-gray, -1 (flat) to 1 (maximum); 0 = unchanged
+	Gamma      float64 // Midtone gamma; above 1 brightens, below 1 darkens (0 is unset, meaning 1.0)
+	BlackPoint uint8   // Input level mapped to black
+	WhitePoint uint8   // Input level mapped to white (0 is treated as 255)
+	Invert     bool    // Flip glyph ordering for light-background terminals (see Palette; shape mode flips matched brightness)
+}
+
+// Validate checks that every adjustment is within range
+func (a Adjustments) Validate() error {
+	if !(a.Brightness >= -1 && a.Brightness <= 1) {
+		return fmt.Errorf("brightness must be between -1 and 1")
+	}
+	if !(a.Contrast >= -1 && a.Contrast <= 1) {
+		return fmt.Errorf("contrast must be between -1 and 1")
+	}
+	if !(a.Gamma >= 0) {
+		return fmt.Errorf("gamma must not be negative")
+	}
+	if a.whitePoint() <= a.BlackPoint {
+		return fmt.Errorf("white point must be greater than black point")
+	}
+	return nil
+}
+
+// IsIdentity reports whether the adjustments leave pixel values unchanged.
+// Invert is not considered, since it is applied to the palette rather than to pixels.
+func (a Adjustments) IsIdentity() bool {
+	return a.Brightness == 0 && a.Contrast == 0 && (a.Gamma == 0 || a.Gamma == 1) &&
+		a.BlackPoint == 0 && a.whitePoint() == 255
+}
+
+func (a Adjustments) whitePoint() uint8 {
+	if a.WhitePoint == 0 {
+		return 255
+	}
+	return a.WhitePoint
+}
+
+// lookupTable builds the 256-entry channel mapping: levels, then gamma, then contrast,
+// then brightness
+func (a Adjustments) lookupTable() [256]uint8 {
+	var table [256]uint8
+
+	black := float64(a.BlackPoint) / 255
+	white := float64(a.whitePoint()) / 255
+	gamma := a.Gamma
+	if gamma == 0 {
+		gamma = 1
+	}
+	// Map contrast -1..1 onto a slope of 0..infinity, with 0 giving a slope of 1
+	slope := math.Tan((math.Max(-1, math.Min(a.Contrast, 0.999)) + 1) * math.Pi / 4)
+
+	for i := range table {
+		v := (float64(i)/255 - black) / (white - black)
+		v = math.Max(0, math.Min(1, v))
+		v = math.Pow(v, 1/gamma)
+		v = (v-0.5)*slope + 0.5
+		v += a.Brightness
+		v = math.Max(0, math.Min(1, v))
+		table[i] = uint8(math.Round(v * 255))
+	}
+	return table
+}
+
+// AdjustImage applies the brightness, contrast, gamma and levels adjustments to every
+// color channel, preserving alpha. When the adjustments are an identity the image is
+// returned unchanged, and an *image.Gray stays grayscale. Invert is handled by Palette so
+// that colors are not negated.
+func AdjustImage(img image.Image, a Adjustments) image.Image {
+	if a.IsIdentity() {
+		return img
+	}
+
+	table := a.lookupTable()
+	if gray, ok := img.(*image.Gray); ok {
+		return ApplyToneTable(gray, table)
+	}
+	bounds := img.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			out.SetNRGBA(x, y, color.NRGBA{R: table[c.R], G: table[c.G], B: table[c.B], A: c.A})
+		}
+	}
+	return out
+}
+
+// Palette returns charPalette with its ordering flipped when Invert is set, so dense glyphs
+// like '@' mark dark areas, as needed on light-background terminals
+func (a Adjustments) Palette(charPalette string) string {
+	if !a.Invert {
+		return charPalette
+	}
+	return ReversePalette(charPalette)
+}
+
+// ReversePalette reverses a character ramp rune by rune
+func ReversePalette(charPalette string) string {
+	runes := []rune(charPalette)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
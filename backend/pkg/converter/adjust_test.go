@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestAdjustmentsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		adjust  Adjustments
+		wantErr bool
+	}{
+		{name: "zero value", adjust: Adjustments{}},
+		{name: "full range", adjust: Adjustments{Brightness: -1, Contrast: 1, Gamma: 2.2, BlackPoint: 10, WhitePoint: 240}},
+		{name: "unset gamma", adjust: Adjustments{Gamma: 0}},
+		{name: "brightness too high", adjust: Adjustments{Brightness: 1.5}, wantErr: true},
+		{name: "NaN brightness", adjust: Adjustments{Brightness: math.NaN()}, wantErr: true},
+		{name: "contrast too low", adjust: Adjustments{Contrast: -2}, wantErr: true},
+		{name: "NaN contrast", adjust: Adjustments{Contrast: math.NaN()}, wantErr: true},
+		{name: "negative gamma", adjust: Adjustments{Gamma: -0.5}, wantErr: true},
+		{name: "NaN gamma", adjust: Adjustments{Gamma: math.NaN()}, wantErr: true},
+		{name: "white below black", adjust: Adjustments{BlackPoint: 200, WhitePoint: 100}, wantErr: true},
+		{name: "black at default white", adjust: Adjustments{BlackPoint: 255}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.adjust.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAdjustImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 1))
+	for x, v := range []uint8{0, 128, 255} {
+		img.SetGray(x, 0, color.Gray{Y: v})
+	}
+
+	tests := []struct {
+		name   string
+		adjust Adjustments
+		want   [3]uint8
+	}{
+		{name: "identity", adjust: Adjustments{Gamma: 1}, want: [3]uint8{0, 128, 255}},
+		{name: "unset gamma", adjust: Adjustments{Gamma: 0, Brightness: 0}, want: [3]uint8{0, 128, 255}},
+		{name: "brightness", adjust: Adjustments{Brightness: 0.2}, want: [3]uint8{51, 179, 255}},
+		{name: "flat contrast", adjust: Adjustments{Contrast: -1}, want: [3]uint8{128, 128, 128}},
+		{name: "gamma brightens", adjust: Adjustments{Gamma: 2}, want: [3]uint8{0, 181, 255}},
+		{name: "levels", adjust: Adjustments{BlackPoint: 64, WhitePoint: 192}, want: [3]uint8{0, 128, 255}},
+	}
+	for _, tt := range tests {
+		out, ok := AdjustImage(img, tt.adjust).(*image.Gray)
+		if !ok {
+			t.Fatalf("%s: grayscale input did not stay grayscale", tt.name)
+		}
+		got := [3]uint8{out.GrayAt(0, 0).Y, out.GrayAt(1, 0).Y, out.GrayAt(2, 0).Y}
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
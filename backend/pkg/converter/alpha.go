@@ -0,0 +1,141 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Transparency handling modes
+const (
+	AlphaComposite = "composite" // Blend transparent pixels over a background color
+	AlphaCutout    = "cutout"    // Cells below the alpha threshold become empty
+)
+
+// AlphaOptions controls how transparent source pixels are rendered
+type AlphaOptions struct {
+	Mode       string // AlphaComposite (default) or AlphaCutout
+	Background RGB    // Color transparent pixels are composited over
+	Threshold  uint8  // Average cell alpha below which a cell is empty (AlphaCutout only)
+}
+
+// DefaultAlphaOptions composites over black, which matches how fully transparent
+// pixels rendered before transparency handling existed
+func DefaultAlphaOptions() AlphaOptions {
+	return AlphaOptions{Mode: AlphaComposite, Threshold: 128}
+}
+
+// Validate checks that the mode is known
+func (o AlphaOptions) Validate() error {
+	switch o.Mode {
+	case "", AlphaComposite, AlphaCutout:
+		return nil
+	default:
+		return fmt.Errorf("unknown alpha mode %q (valid: %s, %s)", o.Mode, AlphaComposite, AlphaCutout)
+	}
+}
+
+// ParseHexColor parses a "#rrggbb" or "rrggbb" color
+func ParseHexColor(s string) (RGB, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return RGB{}, fmt.Errorf("invalid color %q (expected #rrggbb)", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid color %q (expected #rrggbb)", s)
+	}
+	return RGB{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
+
+// CompositeOver blends img over a solid background color and returns an opaque image.
+// Images without an alpha channel (JPEG, grayscale) are returned unchanged.
+func CompositeOver(img image.Image, bg RGB) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// RGBA() is alpha-premultiplied, so compositing is c + bg*(1-a)
+			r, g, b, a := img.At(x, y).RGBA()
+			inv := 0xffff - a
+			out.SetRGBA(x, y, color.RGBA{
+				R: uint8((r + uint32(bg.R)*inv/0xff) >> 8),
+				G: uint8((g + uint32(bg.G)*inv/0xff) >> 8),
+				B: uint8((b + uint32(bg.B)*inv/0xff) >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return out
+}
+
+// TransparentCells reports, for each output cell of cellWidth x cellHeight pixels, whether
+// its average alpha is below threshold. The grid matches the rows and columns produced by
+// the mapper for the same image and cell size.
+func TransparentCells(img image.Image, cellWidth, cellHeight int, threshold uint8) [][]bool {
+	bounds := img.Bounds()
+	rows := (bounds.Dy() + cellHeight - 1) / cellHeight
+	cols := (bounds.Dx() + cellWidth - 1) / cellWidth
+
+	mask := make([][]bool, rows)
+	for row := range mask {
+		mask[row] = make([]bool, cols)
+		for col := range mask[row] {
+			cell := image.Rect(
+				bounds.Min.X+col*cellWidth, bounds.Min.Y+row*cellHeight,
+				bounds.Min.X+(col+1)*cellWidth, bounds.Min.Y+(row+1)*cellHeight,
+			).Intersect(bounds)
+
+			var sum, count uint32
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					_, _, _, a := img.At(x, y).RGBA()
+					sum += a >> 8
+					count++
+				}
+			}
+			mask[row][col] = count > 0 && sum/count < uint32(threshold)
+		}
+	}
+	return mask
+}
+
+// MaskTransparentText replaces the characters of transparent cells with spaces
+func MaskTransparentText(text string, mask [][]bool) string {
+	lines := strings.Split(text, "\n")
+	for row := range lines {
+		if row >= len(mask) {
+			break
+		}
+		runes := []rune(lines[row])
+		for col := range runes {
+			if col < len(mask[row]) && mask[row][col] {
+				runes[col] = ' '
+			}
+		}
+		lines[row] = string(runes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// MaskTransparentColored flags the characters of transparent cells and blanks them.
+// The cells are kept so every line still has the same number of columns.
+func MaskTransparentColored(coloredASCII ColoredASCII, mask [][]bool) ColoredASCII {
+	for row, line := range coloredASCII.Lines {
+		if row >= len(mask) {
+			break
+		}
+		for col := range line {
+			if col < len(mask[row]) && mask[row][col] {
+				line[col] = ColoredChar{Char: " ", Transparent: true}
+			}
+		}
+	}
+	return coloredASCII
+}
@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestAlphaOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    AlphaOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: DefaultAlphaOptions()},
+		{name: "zero value", opts: AlphaOptions{}},
+		{name: "cutout", opts: AlphaOptions{Mode: AlphaCutout, Threshold: 10}},
+		{name: "unknown mode", opts: AlphaOptions{Mode: "ignore"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RGB
+		wantErr bool
+	}{
+		{in: "#ff8000", want: RGB{R: 255, G: 128}},
+		{in: "0A0b0C", want: RGB{R: 10, G: 11, B: 12}},
+		{in: "#000000", want: RGB{}},
+		{in: "", wantErr: true},
+		{in: "#fff", wantErr: true},
+		{in: "#ff80001", wantErr: true},
+		{in: "#gg0000", wantErr: true},
+		{in: "+12345", wantErr: true},
+		{in: "##ff800", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHexColor(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHexColor(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseHexColor(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompositeOver(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{R: 255, A: 128})
+	img.SetNRGBA(2, 0, color.NRGBA{R: 255})
+
+	out := CompositeOver(img, RGB{B: 200})
+	want := []color.RGBA{{R: 255, A: 255}, {R: 128, B: 99, A: 255}, {B: 200, A: 255}}
+	for x, c := range want {
+		if got := color.RGBAModel.Convert(out.At(x, 0)); got != c {
+			t.Errorf("pixel %d: got %v, want %v", x, got, c)
+		}
+	}
+
+	opaque := image.NewGray(image.Rect(0, 0, 2, 2))
+	if out := CompositeOver(opaque, RGB{B: 200}); out != image.Image(opaque) {
+		t.Error("an opaque image was not returned unchanged")
+	}
+}
+
+func TestTransparentCells(t *testing.T) {
+	// 3x3 pixels in 2x2 cells: the right column and bottom row are partial cells
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	img.SetNRGBA(0, 0, color.NRGBA{A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{A: 255})
+	img.SetNRGBA(2, 2, color.NRGBA{A: 200})
+
+	mask := TransparentCells(img, 2, 2, 128)
+	want := [][]bool{{true, true}, {true, false}}
+	if !reflect.DeepEqual(mask, want) {
+		t.Fatalf("TransparentCells = %v, want %v", mask, want)
+	}
+
+	if got := MaskTransparentText("ab\ncd\n", mask); got != "  \n d\n" {
+		t.Errorf("MaskTransparentText = %q", got)
+	}
+	colored := MaskTransparentColored(ColoredASCII{Lines: [][]ColoredChar{
+		{{Char: "a", R: 1}, {Char: "b", R: 2}},
+		{{Char: "c", R: 3}, {Char: "d", R: 4}},
+	}}, mask)
+	if cell := colored.Lines[1][1]; cell.Char != "d" || cell.Transparent {
+		t.Errorf("opaque cell: got %+v", cell)
+	}
+	if cell := colored.Lines[0][1]; cell.Char != " " || !cell.Transparent {
+		t.Errorf("transparent cell: got %+v", cell)
+	}
+}
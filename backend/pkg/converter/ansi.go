@@ -0,0 +1,350 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ANSI color depths
+const (
+	ColorsTrueColor = "truecolor" // 24-bit 38;2;r;g;b escapes
+	Colors256       = "256"       // xterm 256-color cube and grayscale ramp
+	Colors16        = "16"        // The 16 basic terminal colors
+	ColorsNone      = "none"      // Plain text without escapes
+)
+
+// ANSIOptions controls how structured colored output is rendered as ANSI text
+type ANSIOptions struct {
+	Colors string // Color depth: ColorsTrueColor (default), Colors256, Colors16 or ColorsNone
+	Dither bool   // Diffuse quantization error between neighbouring cells (256 and 16 colors only)
+
+	// Tolerance is the CIELAB distance within which a cell reuses the current color instead
+	// of emitting a new escape. 0 only merges identical colors; around 2 is barely visible.
+	Tolerance float64
+}
+
+// Validate checks that the color depth is known and the tolerance is not negative
+func (o ANSIOptions) Validate() error {
+	if !(o.Tolerance >= 0) {
+		return fmt.Errorf("color tolerance must not be negative")
+	}
+	switch o.Colors {
+	case "", ColorsTrueColor, Colors256, Colors16, ColorsNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown color depth %q (valid: %s, %s, %s, %s)", o.Colors, ColorsTrueColor, Colors256, Colors16, ColorsNone)
+	}
+}
+
+// DetectColorDepth guesses the terminal's color depth from the environment:
+// NO_COLOR disables color, COLORTERM=truecolor or 24bit selects 24-bit color,
+// a TERM ending in 256color selects 256 colors, TERM=dumb disables color and
+// anything else gets the 16 basic colors.
+func DetectColorDepth() string {
+	if os.Getenv("NO_COLOR") != "" {
+		return ColorsNone
+	}
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorsTrueColor
+	}
+	term := os.Getenv("TERM")
+	switch {
+	case term == "dumb":
+		return ColorsNone
+	case strings.HasSuffix(term, "256color"):
+		return Colors256
+	default:
+		return Colors16
+	}
+}
+
+// RenderANSI renders structured colored output as text with ANSI color escapes
+// (see WriteANSI)
+func RenderANSI(coloredASCII ColoredASCII, opts ANSIOptions) string {
+	var builder strings.Builder
+	WriteANSI(&builder, coloredASCII, opts)
+	return builder.String()
+}
+
+// WriteANSI streams structured colored output to w as text with ANSI color escapes at the
+// depth selected in opts. Colors are matched to the 256- and 16-color palettes by their
+// nearest CIELAB distance. An escape is only written when the color changes by more than
+// opts.Tolerance, and spaces without a background keep the current foreground color.
+// Transparent cells reset the colors and print a space.
+func WriteANSI(w io.Writer, coloredASCII ColoredASCII, opts ANSIOptions) error {
+	out := bufio.NewWriter(w)
+
+	var palette *ansiPalette
+	switch opts.Colors {
+	case ColorsNone:
+		for _, line := range coloredASCII.Lines {
+			for _, char := range line {
+				out.WriteString(char.Char)
+			}
+			out.WriteString("\n")
+		}
+		return out.Flush()
+	case Colors256:
+		palette = xterm256Palette
+	case Colors16:
+		palette = basic16Palette
+	}
+
+	// Quantize the whole grid up front, since dithering carries error between cells
+	var fgIndexes, bgIndexes [][]int
+	if palette != nil {
+		fgIndexes = palette.quantizeCells(coloredASCII.Lines, func(char ColoredChar) (RGB, bool) {
+			return RGB{R: char.R, G: char.G, B: char.B}, !char.Transparent
+		}, opts.Dither)
+		bgIndexes = palette.quantizeCells(coloredASCII.Lines, func(char ColoredChar) (RGB, bool) {
+			if char.Background == nil || char.Transparent {
+				return RGB{}, false
+			}
+			return *char.Background, true
+		}, opts.Dither)
+	}
+
+	// pen switches the foreground or background to c (the palette color at index when
+	// quantizing), writing an escape only if it differs enough from the current color
+	pen := func(current *ansiPen, c RGB, index int, background bool) {
+		if palette != nil {
+			c = palette.colors[index]
+		}
+		if !current.changes(c, opts.Tolerance) {
+			return
+		}
+		switch {
+		case palette != nil:
+			out.WriteString(palette.escape(index, background))
+		case background:
+			out.WriteString(RGBToANSIBackground(c.R, c.G, c.B))
+		default:
+			out.WriteString(RGBToANSI(c.R, c.G, c.B))
+		}
+		*current = ansiPen{set: true, color: c}
+	}
+
+	for y, line := range coloredASCII.Lines {
+		var fg, bg ansiPen
+		for x, char := range line {
+			if char.Transparent {
+				// Reset so the terminal's own background shows through
+				if fg.set || bg.set {
+					out.WriteString("\033[0m")
+					fg, bg = ansiPen{}, ansiPen{}
+				}
+				out.WriteString(" ")
+				continue
+			}
+
+			if char.Background != nil {
+				pen(&bg, *char.Background, cellIndex(bgIndexes, x, y), true)
+			} else if bg.set {
+				out.WriteString("\033[49m")
+				bg = ansiPen{}
+			}
+			// A space on the default background shows no foreground, so keep the current color
+			if char.Char != " " || char.Background != nil {
+				pen(&fg, RGB{R: char.R, G: char.G, B: char.B}, cellIndex(fgIndexes, x, y), false)
+			}
+			out.WriteString(char.Char)
+		}
+		// Reset color at end of line
+		if fg.set || bg.set {
+			out.WriteString("\033[0m")
+		}
+		out.WriteString("\n")
+	}
+	return out.Flush()
+}
+
+// ansiPen is the foreground or background color last written on the current line
+type ansiPen struct {
+	set   bool
+	color RGB
+}
+
+// changes reports whether switching to c needs an escape: the pen is unset, or c is more
+// than tolerance (CIELAB distance) away from the current color
+func (p ansiPen) changes(c RGB, tolerance float64) bool {
+	if !p.set {
+		return true
+	}
+	if c == p.color {
+		return false
+	}
+	if tolerance <= 0 {
+		return true
+	}
+	a, b := rgbToLab(c), rgbToLab(p.color)
+	dl, da, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dl*dl+da*da+db*db > tolerance*tolerance
+}
+
+// cellIndex returns the quantized palette index of a cell, or -1 without quantization
+func cellIndex(indexes [][]int, x, y int) int {
+	if indexes == nil {
+		return -1
+	}
+	return indexes[y][x]
+}
+
+// ansiPalette is a fixed terminal palette with its colors precomputed in CIELAB
+type ansiPalette struct {
+	colors []RGB
+	lab    [][3]float64
+	escape func(index int, background bool) string
+}
+
+func newANSIPalette(colors []RGB, escape func(index int, background bool) string) *ansiPalette {
+	p := &ansiPalette{colors: colors, escape: escape}
+	for _, c := range colors {
+		p.lab = append(p.lab, rgbToLab(c))
+	}
+	return p
+}
+
+// xterm256Palette holds colors 16-255 of the xterm palette: the 6x6x6 color cube
+// followed by the 24-step grayscale ramp. Colors 0-15 are left out because terminals
+// are free to redefine them.
+var xterm256Palette = func() *ansiPalette {
+	levels := []uint8{0, 95, 135, 175, 215, 255}
+	colors := make([]RGB, 0, 240)
+	for r := 0; r < 6; r++ {
+		for g := 0; g < 6; g++ {
+			for b := 0; b < 6; b++ {
+				colors = append(colors, RGB{R: levels[r], G: levels[g], B: levels[b]})
+			}
+		}
+	}
+	for i := 0; i < 24; i++ {
+		v := uint8(8 + 10*i)
+		colors = append(colors, RGB{R: v, G: v, B: v})
+	}
+	return newANSIPalette(colors, func(index int, background bool) string {
+		if background {
+			return fmt.Sprintf("\033[48;5;%dm", index+16)
+		}
+		return fmt.Sprintf("\033[38;5;%dm", index+16)
+	})
+}()
+
+// basic16Palette holds the 16 basic colors with xterm's default values
+var basic16Palette = newANSIPalette([]RGB{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}, func(index int, background bool) string {
+	code := 30 + index
+	if index >= 8 {
+		code = 90 + index - 8
+	}
+	if background {
+		code += 10
+	}
+	return fmt.Sprintf("\033[%dm", code)
+})
+
+// nearest returns the index of the palette color closest to c in CIELAB
+func (p *ansiPalette) nearest(c RGB) int {
+	lab := rgbToLab(c)
+	best, bestDist := 0, math.Inf(1)
+	for i, candidate := range p.lab {
+		dl, da, db := lab[0]-candidate[0], lab[1]-candidate[1], lab[2]-candidate[2]
+		if dist := dl*dl + da*da + db*db; dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// quantizeCells maps the color colorOf picks from every cell to a palette index, or -1 for
+// cells without that color. With dither the quantization error is spread over the
+// neighbouring cells using Floyd-Steinberg weights.
+func (p *ansiPalette) quantizeCells(lines [][]ColoredChar, colorOf func(ColoredChar) (RGB, bool), dither bool) [][]int {
+	width := 0
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+	// Error buffers are padded by one cell on each side
+	current := make([][3]float64, width+2)
+	next := make([][3]float64, width+2)
+	cache := make(map[RGB]int)
+
+	indexes := make([][]int, len(lines))
+	for y, line := range lines {
+		indexes[y] = make([]int, len(line))
+		for x, char := range line {
+			c, ok := colorOf(char)
+			if !ok {
+				indexes[y][x] = -1
+				continue
+			}
+
+			var want [3]float64
+			if dither {
+				want = [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+				for ch := range want {
+					want[ch] += current[x+1][ch]
+				}
+				c = RGB{R: clampChannel(want[0]), G: clampChannel(want[1]), B: clampChannel(want[2])}
+			}
+
+			index, ok := cache[c]
+			if !ok {
+				index = p.nearest(c)
+				cache[c] = index
+			}
+			indexes[y][x] = index
+
+			if dither {
+				got := p.colors[index]
+				quantErr := [3]float64{want[0] - float64(got.R), want[1] - float64(got.G), want[2] - float64(got.B)}
+				for ch := range quantErr {
+					current[x+2][ch] += quantErr[ch] * 7 / 16
+					next[x][ch] += quantErr[ch] * 3 / 16
+					next[x+1][ch] += quantErr[ch] * 5 / 16
+					next[x+2][ch] += quantErr[ch] * 1 / 16
+				}
+			}
+		}
+		current, next = next, current
+		for i := range next {
+			next[i] = [3]float64{}
+		}
+	}
+	return indexes
+}
+
+// rgbToLab converts an sRGB color to CIELAB (D65 white point)
+func rgbToLab(c RGB) [3]float64 {
+	linear := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.04045 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	r, g, b := linear(c.R), linear(c.G), linear(c.B)
+
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
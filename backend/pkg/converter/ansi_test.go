@@ -0,0 +1,184 @@
+package converter
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestANSIPaletteNearest(t *testing.T) {
+	tests := []struct {
+		name    string
+		palette *ansiPalette
+		color   RGB
+		want    int // Index in the palette; xterm escapes add 16
+	}{
+		{"256 black", xterm256Palette, RGB{0, 0, 0}, 0},
+		{"256 white", xterm256Palette, RGB{255, 255, 255}, 215},
+		{"256 red", xterm256Palette, RGB{255, 0, 0}, 180},
+		{"256 cube color", xterm256Palette, RGB{95, 135, 175}, 51},
+		{"256 near cube color", xterm256Palette, RGB{100, 130, 180}, 51},
+		{"256 gray ramp", xterm256Palette, RGB{128, 128, 128}, 228},
+		{"256 near gray", xterm256Palette, RGB{30, 31, 29}, 218},
+		{"16 black", basic16Palette, RGB{0, 0, 0}, 0},
+		{"16 dark red", basic16Palette, RGB{200, 10, 10}, 1},
+		{"16 bright red", basic16Palette, RGB{255, 0, 0}, 9},
+		{"16 blue", basic16Palette, RGB{0, 0, 230}, 4},
+		{"16 gray", basic16Palette, RGB{120, 125, 130}, 8},
+		{"16 white", basic16Palette, RGB{250, 250, 250}, 15},
+	}
+	for _, tt := range tests {
+		if got := tt.palette.nearest(tt.color); got != tt.want {
+			t.Errorf("%s: nearest(%v) = %d, want %d", tt.name, tt.color, got, tt.want)
+		}
+	}
+}
+
+func TestRenderANSI(t *testing.T) {
+	red := RGB{255, 0, 0}
+	line := func(chars ...ColoredChar) ColoredASCII {
+		return ColoredASCII{Lines: [][]ColoredChar{chars}}
+	}
+	tests := []struct {
+		name  string
+		ascii ColoredASCII
+		opts  ANSIOptions
+		want  string
+	}{
+		{
+			name:  "truecolor merges identical colors",
+			ascii: line(ColoredChar{Char: "a", R: 255}, ColoredChar{Char: "b", R: 255}),
+			opts:  ANSIOptions{Colors: ColorsTrueColor},
+			want:  "\033[38;2;255;0;0mab\033[0m\n",
+		},
+		{
+			name:  "truecolor within tolerance",
+			ascii: line(ColoredChar{Char: "a", R: 200}, ColoredChar{Char: "b", R: 201}),
+			opts:  ANSIOptions{Colors: ColorsTrueColor, Tolerance: 2},
+			want:  "\033[38;2;200;0;0mab\033[0m\n",
+		},
+		{
+			name:  "truecolor beyond tolerance",
+			ascii: line(ColoredChar{Char: "a", R: 200}, ColoredChar{Char: "b", R: 201}),
+			opts:  ANSIOptions{Colors: ColorsTrueColor},
+			want:  "\033[38;2;200;0;0ma\033[38;2;201;0;0mb\033[0m\n",
+		},
+		{
+			name:  "256 colors",
+			ascii: line(ColoredChar{Char: "a", R: 250}, ColoredChar{Char: "b", R: 255}),
+			opts:  ANSIOptions{Colors: Colors256},
+			want:  "\033[38;5;196mab\033[0m\n",
+		},
+		{
+			name:  "16 colors with background",
+			ascii: line(ColoredChar{Char: "a", G: 255, Background: &red}),
+			opts:  ANSIOptions{Colors: Colors16},
+			want:  "\033[101m\033[92ma\033[0m\n",
+		},
+		{
+			name:  "spaces keep the foreground",
+			ascii: line(ColoredChar{Char: "a", R: 255}, ColoredChar{Char: " "}, ColoredChar{Char: "b", R: 255}),
+			opts:  ANSIOptions{Colors: ColorsTrueColor},
+			want:  "\033[38;2;255;0;0ma b\033[0m\n",
+		},
+		{
+			name:  "transparent cells reset",
+			ascii: line(ColoredChar{Char: "a", R: 255}, ColoredChar{Char: "b", Transparent: true}, ColoredChar{Char: "c", R: 255}),
+			opts:  ANSIOptions{Colors: ColorsTrueColor},
+			want:  "\033[38;2;255;0;0ma\033[0m \033[38;2;255;0;0mc\033[0m\n",
+		},
+		{
+			name:  "no colors",
+			ascii: line(ColoredChar{Char: "a", R: 255}, ColoredChar{Char: "b", Background: &red}),
+			opts:  ANSIOptions{Colors: ColorsNone},
+			want:  "ab\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := RenderANSI(tt.ascii, tt.opts); got != tt.want {
+			t.Errorf("%s: RenderANSI = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestANSIOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ANSIOptions
+		wantErr bool
+	}{
+		{name: "zero value", opts: ANSIOptions{}},
+		{name: "256 dithered", opts: ANSIOptions{Colors: Colors256, Dither: true}},
+		{name: "16 colors", opts: ANSIOptions{Colors: Colors16}},
+		{name: "no colors", opts: ANSIOptions{Colors: ColorsNone}},
+		{name: "unknown depth", opts: ANSIOptions{Colors: "8"}, wantErr: true},
+		{name: "tolerance", opts: ANSIOptions{Tolerance: 2.5}},
+		{name: "negative tolerance", opts: ANSIOptions{Tolerance: -1}, wantErr: true},
+		{name: "NaN tolerance", opts: ANSIOptions{Tolerance: math.NaN()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDetectColorDepth(t *testing.T) {
+	tests := []struct {
+		noColor, colorTerm, term string
+		want                     string
+	}{
+		{noColor: "1", colorTerm: "truecolor", term: "xterm-256color", want: ColorsNone},
+		{colorTerm: "truecolor", term: "xterm", want: ColorsTrueColor},
+		{colorTerm: "24BIT", want: ColorsTrueColor},
+		{term: "xterm-256color", want: Colors256},
+		{term: "dumb", want: ColorsNone},
+		{term: "xterm", want: Colors16},
+		{want: Colors16},
+	}
+	for _, tt := range tests {
+		t.Setenv("NO_COLOR", tt.noColor)
+		t.Setenv("COLORTERM", tt.colorTerm)
+		t.Setenv("TERM", tt.term)
+		if got := DetectColorDepth(); got != tt.want {
+			t.Errorf("NO_COLOR=%q COLORTERM=%q TERM=%q: got %q, want %q", tt.noColor, tt.colorTerm, tt.term, got, tt.want)
+		}
+	}
+}
+
+// failingWriter accepts limit bytes, then fails every write
+type failingWriter struct{ limit int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errors.New("disk full")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestWriteANSI(t *testing.T) {
+	// Enough cells to fill the write buffer several times
+	line := make([]ColoredChar, 3000)
+	for i := range line {
+		line[i] = ColoredChar{Char: "#", R: uint8(i), G: uint8(i / 7)}
+	}
+	ascii := ColoredASCII{Lines: [][]ColoredChar{line, line}}
+
+	for _, colors := range []string{ColorsTrueColor, Colors256, Colors16, ColorsNone} {
+		opts := ANSIOptions{Colors: colors}
+		var out strings.Builder
+		if err := WriteANSI(&out, ascii, opts); err != nil {
+			t.Fatalf("%s: %v", colors, err)
+		}
+		if want := RenderANSI(ascii, opts); out.String() != want {
+			t.Errorf("%s: streamed output differs from RenderANSI", colors)
+		}
+		if err := WriteANSI(&failingWriter{limit: 100}, ascii, opts); err == nil {
+			t.Errorf("%s: write error was not returned", colors)
+		}
+	}
+}
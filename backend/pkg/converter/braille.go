@@ -0,0 +1,172 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Braille dot activation modes
+const (
+	DotsThreshold = "threshold" // A dot is raised when its pixel is brighter than the threshold
+	DotsOrdered   = "ordered"   // A 4x4 Bayer matrix varies the threshold per dot to simulate tone
+)
+
+// brailleBase is the blank Braille pattern (U+2800); dots are OR'd onto it
+const brailleBase = 0x2800
+
+// brailleDots maps a pixel position within a 2x4 cell to its Braille dot bit.
+// Indexed as [row][column], following the Unicode dot numbering 1-8.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// BrailleOptions controls how pixels are turned into raised Braille dots
+type BrailleOptions struct {
+	Threshold uint8  // Brightness above which a dot is raised (DotsThreshold only)
+	Dots      string // DotsThreshold or DotsOrdered
+	Invert    bool   // Raise dots for dark pixels instead of bright ones (light-background terminals)
+	GrayModel string // Grayscale model for pixel brightness (see GrayFunc; default GrayRec601)
+}
+
+// DefaultBrailleOptions returns mid-gray thresholding
+func DefaultBrailleOptions() BrailleOptions {
+	return BrailleOptions{Threshold: 128, Dots: DotsThreshold}
+}
+
+// Validate checks that the dot activation mode and grayscale model are known
+func (o BrailleOptions) Validate() error {
+	switch o.Dots {
+	case "", DotsThreshold, DotsOrdered:
+	default:
+		return fmt.Errorf("unknown dot mode %q (valid: %s, %s)", o.Dots, DotsThreshold, DotsOrdered)
+	}
+	return ValidateGrayModel(o.GrayModel)
+}
+
+// ResizeImageForBraille resizes an image so each output character covers a 2x4 pixel block.
+// A character cell is roughly twice as tall as it is wide, so 2x4 pixels per cell makes each
+// pixel square and no extra aspect correction is needed. The height is rounded up to a
+// multiple of 4 so the last row of cells is complete.
+func ResizeImageForBraille(img image.Image, targetWidth int) image.Image {
+	width, height := brailleDimensions(img.Bounds(), targetWidth)
+	return Resample(img, width, height, ResampleLanczos)
+}
+
+// brailleDimensions returns the pixel size ResizeImageForBraille scales to, at most
+// MaxBoxSize cells tall
+func brailleDimensions(bounds image.Rectangle, targetWidth int) (int, int) {
+	originalWidth := bounds.Max.X - bounds.Min.X
+	originalHeight := bounds.Max.Y - bounds.Min.Y
+
+	pixelWidth := targetWidth * 2
+	scale := float64(pixelWidth) / float64(originalWidth)
+	pixelHeight := int(float64(originalHeight) * scale)
+
+	// Round up to a whole number of 4-pixel-tall cells
+	if rem := pixelHeight % 4; rem != 0 {
+		pixelHeight += 4 - rem
+	}
+	pixelHeight = min(max(pixelHeight, 4), 4*MaxBoxSize)
+
+	return pixelWidth, pixelHeight
+}
+
+// dotRaised reports whether the dot at pixel (x, y) should be raised for the given brightness
+func (o BrailleOptions) dotRaised(brightness uint8, x, y int) bool {
+	if o.Invert {
+		brightness = 255 - brightness
+	}
+	if o.Dots == DotsOrdered {
+		// Scale the matrix cell to the centre of its 16-level band (8, 24, ..., 248)
+		limit := bayer4[y&3][x&3]*16 + 8
+		return int(brightness) > limit
+	}
+	return brightness > o.Threshold
+}
+
+// brailleCell returns the Braille pattern for the 2x4 block whose top-left pixel is (x0, y0).
+// Pixels outside bounds are treated as unlit; gray is the brightness function of opts.GrayModel.
+func brailleCell(img image.Image, bounds image.Rectangle, x0, y0 int, opts BrailleOptions, gray func(r, g, b uint32) uint8) rune {
+	cell := rune(brailleBase)
+	for row := 0; row < 4; row++ {
+		y := y0 + row
+		if y >= bounds.Max.Y {
+			break
+		}
+		for col := 0; col < 2; col++ {
+			x := x0 + col
+			if x >= bounds.Max.X {
+				break
+			}
+			r, g, b, _ := img.At(x, y).RGBA()
+			if opts.dotRaised(gray(r, g, b), x-bounds.Min.X, y-bounds.Min.Y) {
+				cell |= brailleDots[row][col]
+			}
+		}
+	}
+	return cell
+}
+
+// ConvertToBraille renders an image as Unicode Braille patterns (U+2800-U+28FF).
+// Each character represents a 2x4 pixel block, so img should come from ResizeImageForBraille.
+func ConvertToBraille(img image.Image, opts BrailleOptions) string {
+	bounds := img.Bounds()
+	gray := GrayFunc(opts.GrayModel)
+	var builder strings.Builder
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 4 {
+		for x := bounds.Min.X; x < bounds.Max.X; x += 2 {
+			builder.WriteRune(brailleCell(img, bounds, x, y, opts, gray))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// ConvertToBrailleWithColorStructured renders an image as Braille patterns with color information.
+// Each character's color is the average color of its 2x4 pixel block.
+func ConvertToBrailleWithColorStructured(img image.Image, opts BrailleOptions) ColoredASCII {
+	bounds := img.Bounds()
+	gray := GrayFunc(opts.GrayModel)
+	lines := make([][]ColoredChar, 0, (bounds.Dy()+3)/4)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 4 {
+		line := make([]ColoredChar, 0, (bounds.Dx()+1)/2)
+		for x := bounds.Min.X; x < bounds.Max.X; x += 2 {
+			avg := averageBlockColor(img, image.Rect(x, y, x+2, y+4).Intersect(bounds))
+			line = append(line, ColoredChar{
+				Char: string(brailleCell(img, bounds, x, y, opts, gray)),
+				R:    avg.R,
+				G:    avg.G,
+				B:    avg.B,
+			})
+		}
+		lines = append(lines, line)
+	}
+
+	return ColoredASCII{Lines: lines}
+}
+
+// averageBlockColor returns the mean color of the pixels in rect
+func averageBlockColor(img image.Image, rect image.Rectangle) color.RGBA {
+	var sumR, sumG, sumB, count uint32
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sumR += r >> 8
+			sumG += g >> 8
+			sumB += b >> 8
+			count++
+		}
+	}
+	if count == 0 {
+		return color.RGBA{A: 255}
+	}
+	return color.RGBA{R: uint8(sumR / count), G: uint8(sumG / count), B: uint8(sumB / count), A: 255}
+}
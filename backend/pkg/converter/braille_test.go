@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBrailleOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    BrailleOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: DefaultBrailleOptions()},
+		{name: "zero value", opts: BrailleOptions{}},
+		{name: "ordered", opts: BrailleOptions{Dots: DotsOrdered, GrayModel: GrayLinear}},
+		{name: "unknown dots", opts: BrailleOptions{Dots: "random"}, wantErr: true},
+		{name: "unknown gray model", opts: BrailleOptions{GrayModel: "luma"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConvertToBraille(t *testing.T) {
+	// One 2x4 cell with the left column lit (dots 1, 2, 3 and 7) and the bottom right
+	// pixel mid-gray
+	img := image.NewGray(image.Rect(0, 0, 2, 4))
+	for y := 0; y < 4; y++ {
+		img.SetGray(0, y, color.Gray{Y: 255})
+	}
+	img.SetGray(1, 3, color.Gray{Y: 128})
+
+	tests := []struct {
+		name string
+		opts BrailleOptions
+		want string
+	}{
+		{name: "threshold", opts: DefaultBrailleOptions(), want: "⡇\n"},
+		{name: "low threshold", opts: BrailleOptions{Threshold: 100}, want: "⣇\n"},
+		{name: "inverted", opts: BrailleOptions{Threshold: 100, Invert: true}, want: "⢸\n"},
+		{name: "ordered", opts: BrailleOptions{Dots: DotsOrdered}, want: "⣇\n"},
+	}
+	for _, tt := range tests {
+		if got := ConvertToBraille(img, tt.opts); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResizeImageForBraille(t *testing.T) {
+	tests := []struct {
+		width, height, targetWidth int
+		wantWidth, wantHeight      int
+	}{
+		{width: 100, height: 100, targetWidth: 50, wantWidth: 100, wantHeight: 100},
+		{width: 100, height: 50, targetWidth: 10, wantWidth: 20, wantHeight: 12},
+		{width: 1000, height: 1, targetWidth: 10, wantWidth: 20, wantHeight: 4},
+	}
+	for _, tt := range tests {
+		size := ResizeImageForBraille(image.NewGray(image.Rect(0, 0, tt.width, tt.height)), tt.targetWidth).Bounds().Size()
+		if w, h := size.X, size.Y; w != tt.wantWidth || h != tt.wantHeight {
+			t.Errorf("%dx%d at width %d: got %dx%d, want %dx%d", tt.width, tt.height, tt.targetWidth, w, h, tt.wantWidth, tt.wantHeight)
+		}
+	}
+}
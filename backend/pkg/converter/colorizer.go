@@ -3,15 +3,25 @@ package converter
 import (
 	"fmt"
 	"image"
-	"strings"
 )
 
-// ColoredChar represents a single character with its RGB color
+// RGB is a 24-bit color
+type RGB struct {
+	R uint8 `json:"r"`
+	G uint8 `json:"g"`
+	B uint8 `json:"b"`
+}
+
+// ColoredChar represents a single character with its RGB color.
+// Background is optional; when nil the character is drawn on the default background.
+// Transparent marks an empty cell (from a transparent source region) that should not be drawn.
 type ColoredChar struct {
-	Char string `json:"char"`
-	R    uint8  `json:"r"`
-	G    uint8  `json:"g"`
-	B    uint8  `json:"b"`
+	Char        string `json:"char"`
+	R           uint8  `json:"r"`
+	G           uint8  `json:"g"`
+	B           uint8  `json:"b"`
+	Background  *RGB   `json:"bg,omitempty"`
+	Transparent bool   `json:"transparent,omitempty"`
 }
 
 // ColoredASCII represents ASCII art with color information as structured data
@@ -25,56 +35,72 @@ func RGBToANSI(r, g, b uint8) string {
 	return ansi
 }
 
-func ConvertToASCIIWithColor(img image.Image, palette string) string {
-	bounds := img.Bounds()
-	// Convert palette type to actual character palette
-	charPalette := GetPalette(palette)
-	var builder strings.Builder
+// RGBToANSIBackground returns the 24-bit ANSI escape that sets the background color
+func RGBToANSIBackground(r, g, b uint8) string {
+	return fmt.Sprintf("\033[48;2;%d;%d;%dm", r, g, b)
+}
 
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
-			r8, g8, b8 := uint8(r>>8), uint8(g>>8), uint8(b>>8)
+// ConvertToASCIIWithColor converts an image to ASCII art wrapped in 24-bit ANSI color escapes,
+// using the named palette. Unknown names return ErrUnknownPalette.
+func ConvertToASCIIWithColor(img image.Image, palette string) (string, error) {
+	coloredASCII, err := ConvertToASCIIWithColorStructured(img, palette)
+	if err != nil {
+		return "", err
+	}
+	return ColoredASCIIToANSI(coloredASCII), nil
+}
 
-			brightness := RGBToGrayScale(r, g, b)
-			char := BrightnessToChar(brightness, charPalette)
+// ColoredASCIIToANSI renders structured colored output as text with 24-bit ANSI color escapes.
+// Characters with a Background also get a background color escape. See WriteANSI for
+// other color depths and for streaming.
+func ColoredASCIIToANSI(coloredASCII ColoredASCII) string {
+	return RenderANSI(coloredASCII, ANSIOptions{Colors: ColorsTrueColor})
+}
 
-			// Write colored character
-			builder.WriteString(fmt.Sprintf("\033[38;2;%d;%d;%dm%s", r8, g8, b8, char))
-		}
-		// Reset color at end of line
-		builder.WriteString("\033[0m\n")
+// ConvertToASCIIWithColorStructured converts an image to ASCII art with color information,
+// using the named palette. Unknown names return ErrUnknownPalette.
+// Returns structured data suitable for JSON serialization (for API responses)
+func ConvertToASCIIWithColorStructured(img image.Image, palette string) (ColoredASCII, error) {
+	charPalette, err := GetPalette(palette)
+	if err != nil {
+		return ColoredASCII{}, err
 	}
+	return ConvertToASCIIWithColorStructuredRamp(img, charPalette), nil
+}
 
-	return builder.String()
+// ConvertToASCIIWithColorStructuredRamp is ConvertToASCIIWithColorStructured with a
+// character ramp (see GetPalette and ResolvePalette) instead of a palette name
+func ConvertToASCIIWithColorStructuredRamp(img image.Image, charPalette string) ColoredASCII {
+	return convertToASCIIWithColorStructured(img, charPalette, RGBToGrayScale)
 }
 
-// ConvertToASCIIWithColorStructured converts an image to ASCII art with color information
-// Returns structured data suitable for JSON serialization (for API responses)
-func ConvertToASCIIWithColorStructured(img image.Image, palette string) ColoredASCII {
+// convertToASCIIWithColorStructured is ConvertToASCIIWithColorStructuredRamp with glyphs
+// picked by the brightness function gray
+func convertToASCIIWithColorStructured(img image.Image, charPalette string, gray func(r, g, b uint32) uint8) ColoredASCII {
 	bounds := img.Bounds()
-	// Convert palette type to actual character palette
-	charPalette := GetPalette(palette)
-	lines := make([][]ColoredChar, 0, bounds.Max.Y-bounds.Min.Y)
+	lines := make([][]ColoredChar, bounds.Max.Y-bounds.Min.Y)
+	glyphs := glyphTable(charPalette)
+	pixel := newPixelReader(img)
 
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		line := make([]ColoredChar, 0, bounds.Max.X-bounds.Min.X)
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
-			r8, g8, b8 := uint8(r>>8), uint8(g>>8), uint8(b>>8)
+	parallelRows(bounds.Min.Y, bounds.Max.Y, func(_, y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			line := make([]ColoredChar, 0, bounds.Max.X-bounds.Min.X)
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r, g, b, _ := pixel(x, y)
+				r8, g8, b8 := uint8(r>>8), uint8(g>>8), uint8(b>>8)
 
-			brightness := RGBToGrayScale(r, g, b)
-			char := BrightnessToChar(brightness, charPalette)
+				brightness := gray(r, g, b)
 
-			line = append(line, ColoredChar{
-				Char: char,
-				R:    r8,
-				G:    g8,
-				B:    b8,
-			})
+				line = append(line, ColoredChar{
+					Char: glyphs[brightness],
+					R:    r8,
+					G:    g8,
+					B:    b8,
+				})
+			}
+			lines[y-bounds.Min.Y] = line
 		}
-		lines = append(lines, line)
-	}
+	})
 
 	return ColoredASCII{Lines: lines}
 }
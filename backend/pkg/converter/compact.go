@@ -0,0 +1,133 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Wire formats for colored output
+const (
+	FormatFull    = "full"    // One {"char","r","g","b"} object per cell (default)
+	FormatCompact = "compact" // Per-line color runs indexing a shared color table
+)
+
+// ValidateFormat checks that format is a known wire format ("" means FormatFull)
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatFull, FormatCompact:
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q (valid: %s, %s)", format, FormatFull, FormatCompact)
+	}
+}
+
+// ColorRun is a run of consecutive characters on one line drawn in the same colors.
+// Color and Background index the shared color table; Background is -1 when the run has
+// no background color and Color is -1 for transparent cells.
+// It is encoded as a JSON array: [text, color] or [text, color, background].
+type ColorRun struct {
+	Text       string
+	Color      int
+	Background int
+}
+
+// MarshalJSON encodes the run as [text, color] or [text, color, background]
+func (r ColorRun) MarshalJSON() ([]byte, error) {
+	if r.Background < 0 {
+		return json.Marshal([]interface{}{r.Text, r.Color})
+	}
+	return json.Marshal([]interface{}{r.Text, r.Color, r.Background})
+}
+
+// ColorTable assigns an index to every distinct color. Its zero value is empty and ready to use.
+type ColorTable struct {
+	Colors [][3]uint8 // Colors as [r, g, b], in order of first use
+	index  map[RGB]int
+}
+
+// Index returns the index of c, adding it to the table if needed
+func (t *ColorTable) Index(c RGB) int {
+	if t.index == nil {
+		t.index = make(map[RGB]int)
+	}
+	if i, ok := t.index[c]; ok {
+		return i
+	}
+	t.index[c] = len(t.Colors)
+	t.Colors = append(t.Colors, [3]uint8{c.R, c.G, c.B})
+	return len(t.Colors) - 1
+}
+
+// CompactLines merges each line of cells into color runs, registering their colors in table
+func CompactLines(lines [][]ColoredChar, table *ColorTable) [][]ColorRun {
+	compact := make([][]ColorRun, len(lines))
+	for y, line := range lines {
+		runs := []ColorRun{}
+		for _, char := range line {
+			run := ColorRun{Text: char.Char, Color: -1, Background: -1}
+			if !char.Transparent {
+				run.Color = table.Index(RGB{R: char.R, G: char.G, B: char.B})
+				if char.Background != nil {
+					run.Background = table.Index(*char.Background)
+				}
+			}
+
+			if last := len(runs) - 1; last >= 0 && runs[last].Color == run.Color && runs[last].Background == run.Background {
+				runs[last].Text += run.Text
+				continue
+			}
+			runs = append(runs, run)
+		}
+		compact[y] = runs
+	}
+	return compact
+}
+
+// CompactColoredASCII is the compact wire format of ColoredASCII
+type CompactColoredASCII struct {
+	Colors [][3]uint8   `json:"colors"`
+	Lines  [][]ColorRun `json:"lines"`
+}
+
+// Compact converts colored output to the compact wire format
+func (c ColoredASCII) Compact() CompactColoredASCII {
+	var table ColorTable
+	lines := CompactLines(c.Lines, &table)
+	return CompactColoredASCII{Colors: table.Colors, Lines: lines}
+}
+
+// CompactFrameColorASCII is the compact wire format of FrameColorASCII. Its runs index the
+// color table shared by the whole clip.
+type CompactFrameColorASCII struct {
+	Index     int          `json:"index"`
+	Timestamp float64      `json:"timestamp"`
+	Lines     [][]ColorRun `json:"lines"`
+}
+
+// CompactVideoColorAsciiResult is the compact wire format of VideoColorAsciiResult
+type CompactVideoColorAsciiResult struct {
+	Format   string                   `json:"format"`
+	Colors   [][3]uint8               `json:"colors"`
+	Frames   []CompactFrameColorASCII `json:"frames"`
+	Metadata VideoMetadata            `json:"metadata"`
+}
+
+// Compact converts a color video result to the compact wire format, with one color table
+// for all frames
+func (v VideoColorAsciiResult) Compact() CompactVideoColorAsciiResult {
+	var table ColorTable
+	frames := make([]CompactFrameColorASCII, len(v.Frames))
+	for i, frame := range v.Frames {
+		frames[i] = CompactFrameColorASCII{
+			Index:     frame.Index,
+			Timestamp: frame.Timestamp,
+			Lines:     CompactLines(frame.Lines, &table),
+		}
+	}
+	return CompactVideoColorAsciiResult{
+		Format:   FormatCompact,
+		Colors:   table.Colors,
+		Frames:   frames,
+		Metadata: v.Metadata,
+	}
+}
@@ -0,0 +1,83 @@
+package converter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCompactLines(t *testing.T) {
+	red, blue := RGB{255, 0, 0}, RGB{0, 0, 255}
+	lines := [][]ColoredChar{
+		{
+			{Char: "a", R: 255}, {Char: "b", R: 255}, {Char: "c", B: 255},
+			{Char: " ", Transparent: true}, {Char: " ", Transparent: true},
+			{Char: "d", R: 255, Background: &blue}, {Char: "e", R: 255, Background: &blue},
+			{Char: "f", R: 255},
+		},
+		{},
+		{{Char: "█", B: 255, Background: &red}, {Char: "g", B: 255}},
+	}
+
+	var table ColorTable
+	compact := CompactLines(lines, &table)
+
+	wantRuns := [][]ColorRun{
+		{{"ab", 0, -1}, {"c", 1, -1}, {"  ", -1, -1}, {"de", 0, 1}, {"f", 0, -1}},
+		{},
+		{{"█", 1, 0}, {"g", 1, -1}},
+	}
+	if !reflect.DeepEqual(compact, wantRuns) {
+		t.Errorf("CompactLines = %v, want %v", compact, wantRuns)
+	}
+	wantColors := [][3]uint8{{255, 0, 0}, {0, 0, 255}}
+	if !reflect.DeepEqual(table.Colors, wantColors) {
+		t.Errorf("color table = %v, want %v", table.Colors, wantColors)
+	}
+
+	if got := expandRuns(compact, table.Colors); !reflect.DeepEqual(got, lines) {
+		t.Errorf("expanded runs = %v, want %v", got, lines)
+	}
+}
+
+// expandRuns turns color runs back into cells, the way a client decodes the compact format
+func expandRuns(runs [][]ColorRun, colors [][3]uint8) [][]ColoredChar {
+	lines := make([][]ColoredChar, len(runs))
+	for y, line := range runs {
+		lines[y] = []ColoredChar{}
+		for _, run := range line {
+			for _, r := range run.Text {
+				char := ColoredChar{Char: string(r), Transparent: run.Color < 0}
+				if run.Color >= 0 {
+					c := colors[run.Color]
+					char.R, char.G, char.B = c[0], c[1], c[2]
+				}
+				if run.Background >= 0 {
+					c := colors[run.Background]
+					char.Background = &RGB{R: c[0], G: c[1], B: c[2]}
+				}
+				lines[y] = append(lines[y], char)
+			}
+		}
+	}
+	return lines
+}
+
+func TestColorRunJSON(t *testing.T) {
+	tests := []struct {
+		run  ColorRun
+		want string
+	}{
+		{ColorRun{Text: "ab", Color: 0, Background: -1}, `["ab",0]`},
+		{ColorRun{Text: "  ", Color: -1, Background: -1}, `["  ",-1]`},
+		{ColorRun{Text: "c", Color: 2, Background: 1}, `["c",2,1]`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.run)
+		if err != nil {
+			t.Errorf("Marshal(%+v) returned error: %v", tt.run, err)
+		} else if string(got) != tt.want {
+			t.Errorf("Marshal(%+v) = %s, want %s", tt.run, got, tt.want)
+		}
+	}
+}
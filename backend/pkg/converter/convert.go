@@ -0,0 +1,353 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// Options configures Convert. Start from DefaultOptions and override what you need.
+type Options struct {
+	Width        int     // Output width in characters (0 derives it from Height)
+	Height       int     // Output height in characters (0 derives it from Width)
+	CharAspect   float64 // Character width divided by height (0 uses the mode's default)
+	Fit          string  // How to fill a Width x Height box: FitContain (default), FitCover or FitStretch
+	Resample     string  // Resampling filter: ResampleLanczos (default), ResampleBox, ResampleBicubic, ...
+	FastGray     bool    // Resize plain text ModeASCII output straight to grayscale (see ResampleGray)
+	GrayModel    string  // How colors become the brightness that picks glyphs: GrayRec601 (default), GrayLinear, ...
+	Palette      string  // Registered palette name (see DefaultPalettes)
+	PaletteChars string  // Inline character ramp ordered dark to bright; overrides Palette
+	Mode         string  // Render mode: ModeASCII, ModeBraille, ModeHalfBlock, ModeEdges or ModeShape
+	Color        bool    // Produce structured colored output instead of plain text
+	Dither       string  // Dithering for plain text ModeASCII output
+
+	Transform TransformOptions // Crop, rotation and flips applied before anything else
+	Braille   BrailleOptions   // Settings for ModeBraille
+	Edge      EdgeOptions      // Settings for ModeEdges
+	Adjust    Adjustments      // Tone adjustments applied after resizing
+	Equalize  EqualizeOptions  // Automatic contrast for plain text output
+	Filters   FilterChain      // Spatial filters applied before or after resizing
+	Alpha     AlphaOptions     // Transparency handling
+}
+
+// DefaultOptions returns grayscale ASCII output 100 characters wide with the normal palette
+func DefaultOptions() Options {
+	return Options{
+		Width:     100,
+		Resample:  ResampleLanczos,
+		GrayModel: GrayRec601,
+		Palette:   PaletteNormal,
+		Mode:      ModeASCII,
+		Dither:    DitherNone,
+		Braille:   DefaultBrailleOptions(),
+		Edge:      DefaultEdgeOptions(),
+		Equalize:  DefaultEqualizeOptions(),
+		Alpha:     DefaultAlphaOptions(),
+	}
+}
+
+// Validate checks every setting so bad input is reported before any image work happens
+func (o Options) Validate() error {
+	if err := o.Transform.Validate(); err != nil {
+		return err
+	}
+	if err := o.box().Validate(); err != nil {
+		return err
+	}
+	if _, err := o.palette(); err != nil {
+		return err
+	}
+	if err := ValidateMode(o.Mode); err != nil {
+		return err
+	}
+	if ModeRequiresColor(o.Mode) && !o.Color {
+		return fmt.Errorf("mode %q requires color output", o.Mode)
+	}
+	if err := ValidateGrayModel(o.GrayModel); err != nil {
+		return err
+	}
+	if err := o.Braille.Validate(); err != nil {
+		return err
+	}
+	if err := o.Edge.Validate(); err != nil {
+		return err
+	}
+	if err := o.Adjust.Validate(); err != nil {
+		return err
+	}
+	if err := o.Equalize.Validate(); err != nil {
+		return err
+	}
+	if err := o.Filters.Validate(); err != nil {
+		return err
+	}
+	if err := o.Alpha.Validate(); err != nil {
+		return err
+	}
+	return ValidateDither(o.Dither)
+}
+
+// Result is the output of Convert
+type Result struct {
+	Text           string        // Plain text output (empty when Options.Color is set)
+	Colored        *ColoredASCII // Structured colored output (nil unless Options.Color is set)
+	Columns        int           // Output width in characters
+	Rows           int           // Output height in characters
+	OriginalWidth  int           // Source image width in pixels, before cropping and rotation
+	OriginalHeight int           // Source image height in pixels, before cropping and rotation
+}
+
+// String returns the plain text output, or the colored output as ANSI escape sequences
+func (r Result) String() string {
+	if r.Colored != nil {
+		return ColoredASCIIToANSI(*r.Colored)
+	}
+	return r.Text
+}
+
+// ANSI returns the output for a terminal: colored output is rendered at the color depth
+// selected in opts (see RenderANSI), plain text is returned as is
+func (r Result) ANSI(opts ANSIOptions) string {
+	if r.Colored != nil {
+		return RenderANSI(*r.Colored, opts)
+	}
+	return r.Text
+}
+
+// WriteANSI streams the output for a terminal to w (see ANSI)
+func (r Result) WriteANSI(w io.Writer, opts ANSIOptions) error {
+	if r.Colored != nil {
+		return WriteANSI(w, *r.Colored, opts)
+	}
+	_, err := io.WriteString(w, r.Text)
+	return err
+}
+
+// SVG exports the result as an SVG image (see ConvertToSVG)
+func (r Result) SVG(opts SVGOptions) string {
+	return ConvertToSVG(r.Text, r.Colored, opts)
+}
+
+// HTML exports the result as an HTML document or fragment (see ConvertToHTML)
+func (r Result) HTML(opts HTMLOptions) string {
+	return ConvertToHTML(r.Text, r.Colored, opts)
+}
+
+// Image draws the result with the embedded bitmap font (see RasterizeASCII)
+func (r Result) Image(opts RasterOptions) (*image.RGBA, error) {
+	return RasterizeASCII(r.Text, r.Colored, opts)
+}
+
+// WriteImage encodes the result as a PNG or JPEG image to w (see WriteRaster)
+func (r Result) WriteImage(w io.Writer, opts RasterOptions) error {
+	return WriteRaster(w, r.Text, r.Colored, opts)
+}
+
+// Convert runs the whole pipeline on img: geometric transforms, transparency handling,
+// filters, resizing, tone adjustments, automatic contrast, dithering and character mapping
+// in the selected mode.
+func Convert(img image.Image, opts Options) (Result, error) {
+	if err := opts.Validate(); err != nil {
+		return Result{}, err
+	}
+	charPalette, _ := opts.palette()
+
+	bounds := img.Bounds()
+	result := Result{OriginalWidth: bounds.Dx(), OriginalHeight: bounds.Dy()}
+	resizedImg, err := opts.prepare(img)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if opts.Color {
+		coloredASCII := opts.mapColored(resizedImg, charPalette)
+		result.Colored = &coloredASCII
+		result.Rows = len(coloredASCII.Lines)
+		if result.Rows > 0 {
+			result.Columns = len(coloredASCII.Lines[0])
+		}
+		return result, nil
+	}
+
+	// Equalization works on grayscale, so only convert when it is enabled
+	grayScaleImg := resizedImg
+	if opts.Equalize.Method != "" && opts.Equalize.Method != EqualizeNone {
+		grayScaleImg = Equalize(resizedImg, opts.equalizeOptions())
+	}
+	result.Text = opts.mapText(resizedImg, grayScaleImg, charPalette)
+	lines := strings.Split(strings.TrimSuffix(result.Text, "\n"), "\n")
+	result.Rows = len(lines)
+	result.Columns = len([]rune(lines[0]))
+	return result, nil
+}
+
+// palette resolves the character ramp, reversed when Adjust.Invert is set
+func (o Options) palette() (string, error) {
+	charPalette, err := DefaultPalettes.Resolve(o.Palette, o.PaletteChars)
+	if err != nil {
+		return "", err
+	}
+	return o.Adjust.Palette(charPalette), nil
+}
+
+// prepare runs every stage up to character mapping: transforms, compositing, pre-resize
+// filters, resizing, post-resize filters and tone adjustments. A width alone with the default aspect
+// ratio keeps the proportions of the mode's own resize function (ResizeImage,
+// ResizeImageForBraille, ...); a height or aspect ratio goes through ResizeImageToBox.
+// With FastGray, plain text ModeASCII output is resized straight to grayscale (see ResampleGray).
+func (o Options) prepare(img image.Image) (image.Image, error) {
+	img, err := o.Transform.Apply(img)
+	if err != nil {
+		return nil, err
+	}
+	if o.Alpha.Mode != AlphaCutout {
+		img = CompositeOver(img, o.Alpha.Background)
+	}
+	img = o.Filters.BeforeResize(img)
+
+	box := o.box()
+	cellWidth, cellHeight := CellSize(o.Mode)
+	var resizedImg image.Image
+	if o.Height > 0 || o.CharAspect > 0 {
+		resizedImg = ResizeImageToBox(img, box, cellWidth, cellHeight)
+	} else {
+		var width, height int
+		switch o.Mode {
+		case ModeBraille:
+			width, height = brailleDimensions(img.Bounds(), o.Width)
+		case ModeHalfBlock:
+			width, height = halfBlockDimensions(img.Bounds(), o.Width)
+		case ModeShape:
+			width, height = cellDimensions(img.Bounds(), o.Width, cellWidth, cellHeight)
+		default:
+			width, height = resizeDimensions(img.Bounds(), o.Width)
+		}
+		resizedImg = box.resample(img, width, height)
+	}
+
+	return AdjustImage(o.Filters.AfterResize(resizedImg), o.Adjust), nil
+}
+
+// mapText converts a prepared image to plain text. grayScaleImg is either resizedImg itself
+// or its (equalized) grayscale version; ModeASCII converts it to grayscale when needed.
+func (o Options) mapText(resizedImg, grayScaleImg image.Image, charPalette string) string {
+	var text string
+	switch o.Mode {
+	case ModeBraille:
+		text = ConvertToBraille(grayScaleImg, o.brailleOptions())
+	case ModeEdges:
+		text = ConvertToASCIIWithEdges(grayScaleImg, charPalette, o.edgeOptions())
+	case ModeShape:
+		text = convertToASCIIByShape(grayScaleImg, charPalette, o.shapeGray())
+	default:
+		if grayScaleImg == resizedImg {
+			grayScaleImg = o.toGray(resizedImg)
+		}
+		ditheredImg := DitherForPalette(grayScaleImg, charPalette, o.Dither)
+		text = ConvertToASCIIWithRamp(ditheredImg, charPalette)
+	}
+
+	if mask := o.transparentCells(resizedImg); mask != nil {
+		text = MaskTransparentText(text, mask)
+	}
+	return text
+}
+
+// mapColored converts a prepared image to structured colored output
+func (o Options) mapColored(resizedImg image.Image, charPalette string) ColoredASCII {
+	var coloredASCII ColoredASCII
+	switch o.Mode {
+	case ModeHalfBlock:
+		coloredASCII = ConvertToHalfBlockStructured(resizedImg)
+	case ModeBraille:
+		coloredASCII = ConvertToBrailleWithColorStructured(resizedImg, o.brailleOptions())
+	case ModeEdges:
+		coloredASCII = ConvertToASCIIWithEdgesColorStructured(resizedImg, charPalette, o.edgeOptions())
+	case ModeShape:
+		coloredASCII = convertToASCIIByShapeColorStructured(resizedImg, charPalette, o.shapeGray())
+	default:
+		coloredASCII = convertToASCIIWithColorStructured(resizedImg, charPalette, GrayFunc(o.GrayModel))
+	}
+
+	if mask := o.transparentCells(resizedImg); mask != nil {
+		coloredASCII = MaskTransparentColored(coloredASCII, mask)
+	}
+	return coloredASCII
+}
+
+// box returns the output size settings. The default aspect ratio is DefaultCharAspect,
+// except in ModeShape where glyphs are matched at the embedded font's cell proportions.
+// Contain padding uses the alpha background, or stays transparent for cutout. Plain text
+// ModeASCII output is only ever read as grayscale, so with FastGray it is resized straight
+// to grayscale unless cutout still needs the alpha channel. That pass rounds differently
+// from resizing in color, so it is opt-in to keep the default output unchanged.
+func (o Options) box() BoxOptions {
+	box := BoxOptions{
+		Width:      o.Width,
+		Height:     o.Height,
+		CharAspect: o.CharAspect,
+		Fit:        o.Fit,
+		Resample:   o.Resample,
+		Grayscale:  o.FastGray && !o.Color && o.Mode == ModeASCII && o.Alpha.Mode != AlphaCutout,
+		GrayModel:  o.GrayModel,
+	}
+	if box.CharAspect == 0 && o.Mode == ModeShape {
+		box.CharAspect = float64(FontCellWidth) / FontCellHeight
+	}
+	if o.Alpha.Mode != AlphaCutout {
+		bg := o.Alpha.Background
+		box.Padding = color.RGBA{R: bg.R, G: bg.G, B: bg.B, A: 0xff}
+	}
+	return box
+}
+
+// transparentCells returns the empty-cell mask for cutout transparency, or nil otherwise
+func (o Options) transparentCells(resizedImg image.Image) [][]bool {
+	if o.Alpha.Mode != AlphaCutout {
+		return nil
+	}
+	cellWidth, cellHeight := CellSize(o.Mode)
+	return TransparentCells(resizedImg, cellWidth, cellHeight, o.Alpha.Threshold)
+}
+
+// brailleOptions returns the Braille settings with inversion taken from the adjustments
+// and the grayscale model from the options
+func (o Options) brailleOptions() BrailleOptions {
+	opts := o.Braille
+	opts.Invert = opts.Invert || o.Adjust.Invert
+	opts.GrayModel = o.GrayModel
+	return opts
+}
+
+// shapeGray returns the brightness function glyph shapes are matched against. Shape matching
+// ignores the ramp order, so Adjust.Invert flips the brightness instead.
+func (o Options) shapeGray() func(r, g, b uint32) uint8 {
+	gray := GrayFunc(o.GrayModel)
+	if !o.Adjust.Invert {
+		return gray
+	}
+	return func(r, g, b uint32) uint8 {
+		return 255 - gray(r, g, b)
+	}
+}
+
+// equalizeOptions returns the equalization settings with the grayscale model from the options
+func (o Options) equalizeOptions() EqualizeOptions {
+	opts := o.Equalize
+	opts.GrayModel = o.GrayModel
+	return opts
+}
+
+// edgeOptions returns the edge settings with the grayscale model from the options
+func (o Options) edgeOptions() EdgeOptions {
+	opts := o.Edge
+	opts.GrayModel = o.GrayModel
+	return opts
+}
+
+// toGray returns img as *image.Gray, converting it with the grayscale model if necessary
+func (o Options) toGray(img image.Image) *image.Gray {
+	return toGray(img, o.GrayModel)
+}
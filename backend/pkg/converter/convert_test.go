@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Options)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(o *Options) {}},
+		{name: "inline palette", modify: func(o *Options) { o.Palette, o.PaletteChars = "missing", " .#" }},
+		{name: "color mode", modify: func(o *Options) { o.Mode, o.Color = ModeHalfBlock, true }},
+		{name: "no width", modify: func(o *Options) { o.Width = 0 }, wantErr: true},
+		{name: "negative width", modify: func(o *Options) { o.Width = -5 }, wantErr: true},
+		{name: "unknown palette", modify: func(o *Options) { o.Palette = "missing" }, wantErr: true},
+		{name: "unknown mode", modify: func(o *Options) { o.Mode = "sixel" }, wantErr: true},
+		{name: "halfblock without color", modify: func(o *Options) { o.Mode = ModeHalfBlock }, wantErr: true},
+		{name: "unknown dither", modify: func(o *Options) { o.Dither = "noise" }, wantErr: true},
+		{name: "bad braille", modify: func(o *Options) { o.Braille.Dots = "random" }, wantErr: true},
+		{name: "bad edges", modify: func(o *Options) { o.Edge.Operator = "canny" }, wantErr: true},
+		{name: "bad adjustments", modify: func(o *Options) { o.Adjust.Contrast = 2 }, wantErr: true},
+		{name: "bad equalization", modify: func(o *Options) { o.Equalize.Method = "auto" }, wantErr: true},
+		{name: "bad filters", modify: func(o *Options) { o.Filters.Stage = "during" }, wantErr: true},
+		{name: "bad alpha", modify: func(o *Options) { o.Alpha.Mode = "ignore" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		tt.modify(&opts)
+		if err := opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 80, 40))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+
+	tests := []struct {
+		name          string
+		modify        func(*Options)
+		columns, rows int
+	}{
+		{name: "ascii", modify: func(o *Options) {}, columns: 20, rows: 5},
+		{name: "ascii color", modify: func(o *Options) { o.Color = true }, columns: 20, rows: 5},
+		{name: "braille", modify: func(o *Options) { o.Mode = ModeBraille }, columns: 20, rows: 5},
+		{name: "halfblock", modify: func(o *Options) { o.Mode, o.Color = ModeHalfBlock, true }, columns: 20, rows: 5},
+		{name: "edges", modify: func(o *Options) { o.Mode = ModeEdges }, columns: 20, rows: 5},
+		{name: "shape", modify: func(o *Options) { o.Mode = ModeShape }, columns: 20, rows: 5},
+		{name: "dithered", modify: func(o *Options) { o.Dither = DitherFloydSteinberg }, columns: 20, rows: 5},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Width = 20
+		tt.modify(&opts)
+		result, err := Convert(img, opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if result.Columns != tt.columns || result.Rows != tt.rows {
+			t.Errorf("%s: got %dx%d characters, want %dx%d", tt.name, result.Columns, result.Rows, tt.columns, tt.rows)
+		}
+		if result.OriginalWidth != 80 || result.OriginalHeight != 40 {
+			t.Errorf("%s: original size %dx%d, want 80x40", tt.name, result.OriginalWidth, result.OriginalHeight)
+		}
+		if opts.Color != (result.Colored != nil) || opts.Color == (result.Text != "") {
+			t.Errorf("%s: got text %t and colored output %t, want colored output %t", tt.name, result.Text != "", result.Colored != nil, opts.Color)
+		}
+		if !opts.Color && strings.Count(result.Text, "\n") != tt.rows {
+			t.Errorf("%s: text has %d lines, want %d", tt.name, strings.Count(result.Text, "\n"), tt.rows)
+		}
+	}
+
+	if _, err := Convert(img, Options{}); err == nil {
+		t.Error("Convert accepted zero options")
+	}
+}
@@ -0,0 +1,186 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"unicode/utf8"
+)
+
+// Dithering methods
+const (
+	DitherNone           = "none"
+	DitherFloydSteinberg = "floyd-steinberg"
+	DitherAtkinson       = "atkinson"
+	DitherSierra         = "sierra"
+	DitherBayer2         = "bayer2"
+	DitherBayer4         = "bayer4"
+	DitherBayer8         = "bayer8"
+)
+
+// diffusionWeight is one neighbour that receives part of a pixel's quantization error
+type diffusionWeight struct {
+	dx, dy int
+	weight float64
+}
+
+// Error diffusion kernels. Weights are fractions of the quantization error;
+// Atkinson intentionally diffuses only 6/8 of the error for higher contrast.
+var diffusionKernels = map[string][]diffusionWeight{
+	DitherFloydSteinberg: {
+		{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16},
+	},
+	DitherAtkinson: {
+		{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8},
+		{-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8},
+		{0, 2, 1.0 / 8},
+	},
+	DitherSierra: {
+		{1, 0, 5.0 / 32}, {2, 0, 3.0 / 32},
+		{-2, 1, 2.0 / 32}, {-1, 1, 4.0 / 32}, {0, 1, 5.0 / 32}, {1, 1, 4.0 / 32}, {2, 1, 2.0 / 32},
+		{-1, 2, 2.0 / 32}, {0, 2, 3.0 / 32}, {1, 2, 2.0 / 32},
+	},
+}
+
+// Ordered dithering threshold matrices
+var (
+	bayer2 = bayerMatrix(2)
+	bayer4 = bayerMatrix(4)
+	bayer8 = bayerMatrix(8)
+)
+
+// bayerMatrix builds an n x n Bayer index matrix (n must be a power of two)
+// with values 0 to n*n-1
+func bayerMatrix(n int) [][]int {
+	m := [][]int{{0}}
+	for size := 1; size < n; size *= 2 {
+		next := make([][]int, size*2)
+		for y := range next {
+			next[y] = make([]int, size*2)
+		}
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				v := m[y][x] * 4
+				next[y][x] = v
+				next[y][x+size] = v + 2
+				next[y+size][x] = v + 3
+				next[y+size][x+size] = v + 1
+			}
+		}
+		m = next
+	}
+	return m
+}
+
+// ValidateDither checks that method is a known dithering method
+func ValidateDither(method string) error {
+	switch method {
+	case "", DitherNone, DitherFloydSteinberg, DitherAtkinson, DitherSierra,
+		DitherBayer2, DitherBayer4, DitherBayer8:
+		return nil
+	default:
+		return fmt.Errorf("unknown dither method %q (valid: %s, %s, %s, %s, %s, %s, %s)", method,
+			DitherNone, DitherFloydSteinberg, DitherAtkinson, DitherSierra, DitherBayer2, DitherBayer4, DitherBayer8)
+	}
+}
+
+// DitherForPalette dithers a grayscale image down to the glyph count of charPalette,
+// so that ConvertToASCIIWithRamp maps each pixel onto the glyph the ditherer chose.
+func DitherForPalette(img image.Image, charPalette string, method string) image.Image {
+	return DitherGrayscale(img, utf8.RuneCountInString(charPalette), method)
+}
+
+// DitherGrayscale quantizes a grayscale image to the given number of evenly spaced levels.
+// Output values are chosen so that BrightnessToChar maps level k to the k-th glyph of a
+// palette with that many glyphs. With DitherNone (or fewer than two levels) the image is
+// returned unchanged, leaving quantization to BrightnessToChar.
+func DitherGrayscale(img image.Image, levels int, method string) image.Image {
+	if method == "" || method == DitherNone || levels < 2 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Work in float so error can carry outside the 0-255 range. Gray input is read as is,
+	// since it may come from any grayscale model.
+	values := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			values[y*width+x] = float64(grayAt(img, bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	step := 255.0 / float64(levels-1)
+	outputs := levelOutputs(levels)
+	out := image.NewGray(bounds)
+
+	quantize := func(v float64) int {
+		level := int(math.Round(v / step))
+		if level < 0 {
+			return 0
+		}
+		if level >= levels {
+			return levels - 1
+		}
+		return level
+	}
+
+	if kernel, ok := diffusionKernels[method]; ok {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				v := values[y*width+x]
+				level := quantize(v)
+				out.SetGray(bounds.Min.X+x, bounds.Min.Y+y, color.Gray{Y: outputs[level]})
+
+				quantErr := v - float64(level)*step
+				for _, k := range kernel {
+					nx, ny := x+k.dx, y+k.dy
+					if nx < 0 || nx >= width || ny >= height {
+						continue
+					}
+					values[ny*width+nx] += quantErr * k.weight
+				}
+			}
+		}
+		return out
+	}
+
+	var matrix [][]int
+	switch method {
+	case DitherBayer2:
+		matrix = bayer2
+	case DitherBayer8:
+		matrix = bayer8
+	default:
+		matrix = bayer4
+	}
+	n := len(matrix)
+	cells := float64(n * n)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Offset by a threshold in [-0.5, 0.5) of one quantization step
+			offset := (float64(matrix[y%n][x%n])+0.5)/cells - 0.5
+			level := quantize(values[y*width+x] + offset*step)
+			out.SetGray(bounds.Min.X+x, bounds.Min.Y+y, color.Gray{Y: outputs[level]})
+		}
+	}
+	return out
+}
+
+// levelOutputs returns, for each level, the smallest brightness that BrightnessToChar
+// maps to that level's glyph. Deriving it from the mapper's own formula keeps the two in sync.
+func levelOutputs(levels int) []uint8 {
+	outputs := make([]uint8, levels)
+	next := 0
+	for v := 0; v <= 255 && next < levels; v++ {
+		index := int(float64(v) / 255.0 * float64(levels-1))
+		for next <= index {
+			outputs[next] = uint8(v)
+			next++
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,82 @@
+package converter
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestValidateDither(t *testing.T) {
+	for _, method := range []string{"", DitherNone, DitherFloydSteinberg, DitherAtkinson, DitherSierra, DitherBayer2, DitherBayer4, DitherBayer8} {
+		if err := ValidateDither(method); err != nil {
+			t.Errorf("ValidateDither(%q) = %v", method, err)
+		}
+	}
+	for _, method := range []string{"bayer16", "Atkinson", "random"} {
+		if err := ValidateDither(method); err == nil {
+			t.Errorf("ValidateDither(%q) accepted an unknown method", method)
+		}
+	}
+}
+
+func TestBayerMatrix(t *testing.T) {
+	for _, n := range []int{2, 4, 8} {
+		seen := make([]bool, n*n)
+		for _, row := range bayerMatrix(n) {
+			for _, v := range row {
+				if v < 0 || v >= n*n || seen[v] {
+					t.Fatalf("bayerMatrix(%d): value %d is out of range or repeated", n, v)
+				}
+				seen[v] = true
+			}
+		}
+	}
+}
+
+// Each level's output must map to that level's glyph
+func TestLevelOutputs(t *testing.T) {
+	for _, palette := range []string{" @", " .:-=+*#%@", " .'`^\",:;Il!i><~+_-?][}{1)(|\\/tfjrxnuvczXYUJCLQ0OZmwqpdbkhao*#MW&8%B@$"} {
+		glyphs := []rune(palette)
+		for level, v := range levelOutputs(len(glyphs)) {
+			if got := BrightnessToChar(v, palette); got != string(glyphs[level]) {
+				t.Errorf("%d levels: level %d outputs %d, which maps to %q instead of %q", len(glyphs), level, v, got, string(glyphs[level]))
+			}
+		}
+	}
+}
+
+func TestDitherGrayscale(t *testing.T) {
+	const size = 32
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = 64
+	}
+
+	for _, method := range []string{DitherFloydSteinberg, DitherAtkinson, DitherSierra, DitherBayer2, DitherBayer4, DitherBayer8} {
+		out := DitherGrayscale(img, 2, method).(*image.Gray)
+		lit := 0
+		for _, v := range out.Pix {
+			switch v {
+			case 255:
+				lit++
+			case 0:
+			default:
+				t.Fatalf("%s: output level %d, want 0 or 255", method, v)
+			}
+		}
+		// The share of lit pixels approximates the input brightness, except with Atkinson,
+		// which drops a quarter of the error for extra contrast
+		if share := float64(lit) / (size * size); method != DitherAtkinson && math.Abs(share-64.0/255) > 0.05 {
+			t.Errorf("%s: %.3f of pixels lit, want about %.3f", method, share, 64.0/255)
+		}
+	}
+
+	for _, tt := range []struct {
+		method string
+		levels int
+	}{{DitherNone, 10}, {"", 10}, {DitherFloydSteinberg, 1}} {
+		if out := DitherGrayscale(img, tt.levels, tt.method); out != image.Image(img) {
+			t.Errorf("%q with %d levels: image was not returned unchanged", tt.method, tt.levels)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// Edge detection operators
+const (
+	EdgeSobel  = "sobel"
+	EdgeScharr = "scharr"
+)
+
+// EdgeOptions controls edge-aware directional character mapping
+type EdgeOptions struct {
+	Operator  string  // EdgeSobel or EdgeScharr
+	Threshold float64 // Gradient magnitude (0-360, in brightness units) above which an edge glyph is used
+	GrayModel string  // Grayscale model for pixel brightness (see GrayFunc; default GrayRec601)
+}
+
+// DefaultEdgeOptions returns Sobel edges with a moderate threshold
+func DefaultEdgeOptions() EdgeOptions {
+	return EdgeOptions{Operator: EdgeSobel, Threshold: 64}
+}
+
+// Validate checks that the operator and grayscale model are known and the threshold is usable
+func (o EdgeOptions) Validate() error {
+	switch o.Operator {
+	case "", EdgeSobel, EdgeScharr:
+	default:
+		return fmt.Errorf("unknown edge operator %q (valid: %s, %s)", o.Operator, EdgeSobel, EdgeScharr)
+	}
+	if !(o.Threshold >= 0) {
+		return fmt.Errorf("edge threshold must not be negative")
+	}
+	return ValidateGrayModel(o.GrayModel)
+}
+
+// edgeKernel returns the smoothing weights (outer, centre) of the operator's 3x3 kernel
+// and their total, used to normalize gradients back to brightness units
+func (o EdgeOptions) edgeKernel() (outer, centre, total float64) {
+	if o.Operator == EdgeScharr {
+		return 3, 10, 16
+	}
+	return 1, 2, 4
+}
+
+// edgeGradients computes the horizontal and vertical gradient of the image's brightness.
+// Gradients are normalized by the kernel weight so they are in brightness units (-255 to 255).
+// Border pixels are clamped to the nearest edge pixel.
+func edgeGradients(img image.Image, opts EdgeOptions) (gx, gy []float64, width, height int) {
+	bounds := img.Bounds()
+	width, height = bounds.Dx(), bounds.Dy()
+
+	brightness := GrayFunc(opts.GrayModel)
+	gray := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			gray[y*width+x] = float64(brightness(r, g, b))
+		}
+	}
+
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), width-1)
+		y = min(max(y, 0), height-1)
+		return gray[y*width+x]
+	}
+
+	outer, centre, total := opts.edgeKernel()
+	gx = make([]float64, width*height)
+	gy = make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gx[y*width+x] = (outer*(at(x+1, y-1)-at(x-1, y-1)) +
+				centre*(at(x+1, y)-at(x-1, y)) +
+				outer*(at(x+1, y+1)-at(x-1, y+1))) / total
+			gy[y*width+x] = (outer*(at(x-1, y+1)-at(x-1, y-1)) +
+				centre*(at(x, y+1)-at(x, y-1)) +
+				outer*(at(x+1, y+1)-at(x+1, y-1))) / total
+		}
+	}
+	return gx, gy, width, height
+}
+
+// EdgeToChar returns the directional glyph for a gradient, or "" when the gradient
+// magnitude is below threshold. The glyph follows the edge, which runs perpendicular
+// to the gradient: a horizontal gradient gives "|", a vertical one gives "-" or "_"
+// ("_" when the region below is brighter, so the line hugs the top of bright shapes).
+func EdgeToChar(gx, gy, threshold float64) string {
+	if math.Hypot(gx, gy) <= threshold {
+		return ""
+	}
+
+	// Gradient angle folded into [0, 180) degrees; image y grows downwards
+	angle := math.Atan2(gy, gx) * 180 / math.Pi
+	if angle < 0 {
+		angle += 180
+	}
+
+	switch {
+	case angle < 22.5 || angle >= 157.5:
+		return "|"
+	case angle < 67.5:
+		return "/"
+	case angle < 112.5:
+		if gy > 0 {
+			return "_"
+		}
+		return "-"
+	default:
+		return "\\"
+	}
+}
+
+// ConvertToASCIIWithEdges maps an image to ASCII art, drawing directional edge glyphs
+// where the gradient is strong and falling back to charPalette brightness glyphs elsewhere.
+func ConvertToASCIIWithEdges(img image.Image, charPalette string, opts EdgeOptions) string {
+	gx, gy, width, height := edgeGradients(img, opts)
+	bounds := img.Bounds()
+	gray := GrayFunc(opts.GrayModel)
+	glyphs := glyphTable(charPalette)
+	var builder strings.Builder
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			char := EdgeToChar(gx[i], gy[i], opts.Threshold)
+			if char == "" {
+				r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+				char = glyphs[gray(r, g, b)]
+			}
+			builder.WriteString(char)
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// ConvertToASCIIWithEdgesColorStructured is the colored counterpart of ConvertToASCIIWithEdges.
+// Each character keeps the color of its source pixel.
+func ConvertToASCIIWithEdgesColorStructured(img image.Image, charPalette string, opts EdgeOptions) ColoredASCII {
+	gx, gy, width, height := edgeGradients(img, opts)
+	bounds := img.Bounds()
+	gray := GrayFunc(opts.GrayModel)
+	glyphs := glyphTable(charPalette)
+	lines := make([][]ColoredChar, 0, height)
+
+	for y := 0; y < height; y++ {
+		line := make([]ColoredChar, 0, width)
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			i := y*width + x
+			char := EdgeToChar(gx[i], gy[i], opts.Threshold)
+			if char == "" {
+				char = glyphs[gray(r, g, b)]
+			}
+			line = append(line, ColoredChar{
+				Char: char,
+				R:    uint8(r >> 8),
+				G:    uint8(g >> 8),
+				B:    uint8(b >> 8),
+			})
+		}
+		lines = append(lines, line)
+	}
+
+	return ColoredASCII{Lines: lines}
+}
@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEdgeOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    EdgeOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: DefaultEdgeOptions()},
+		{name: "scharr", opts: EdgeOptions{Operator: EdgeScharr, Threshold: 0}},
+		{name: "unknown operator", opts: EdgeOptions{Operator: "canny"}, wantErr: true},
+		{name: "negative threshold", opts: EdgeOptions{Threshold: -1}, wantErr: true},
+		{name: "NaN threshold", opts: EdgeOptions{Threshold: math.NaN()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEdgeToChar(t *testing.T) {
+	tests := []struct {
+		gx, gy, threshold float64
+		want              string
+	}{
+		{gx: 10, gy: 0, threshold: 20, want: ""},
+		{gx: 100, gy: 0, threshold: 20, want: "|"},
+		{gx: -100, gy: 0, threshold: 20, want: "|"},
+		{gx: 0, gy: 100, threshold: 20, want: "_"},
+		{gx: 0, gy: -100, threshold: 20, want: "-"},
+		{gx: 100, gy: 100, threshold: 20, want: "/"},
+		{gx: -100, gy: -100, threshold: 20, want: "/"},
+		{gx: 100, gy: -100, threshold: 20, want: "\\"},
+		{gx: 30, gy: 0, threshold: 30, want: ""},
+	}
+	for _, tt := range tests {
+		if got := EdgeToChar(tt.gx, tt.gy, tt.threshold); got != tt.want {
+			t.Errorf("EdgeToChar(%g, %g, %g) = %q, want %q", tt.gx, tt.gy, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestConvertToASCIIWithEdges(t *testing.T) {
+	// Dark left half, bright right half: a vertical edge down the middle
+	img := image.NewGray(image.Rect(0, 0, 6, 3))
+	for y := 0; y < 3; y++ {
+		for x := 3; x < 6; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	for _, operator := range []string{EdgeSobel, EdgeScharr} {
+		got := ConvertToASCIIWithEdges(img, " @", EdgeOptions{Operator: operator, Threshold: 64})
+		if want := strings.Repeat("  ||@@\n", 3); got != want {
+			t.Errorf("%s: got %q, want %q", operator, got, want)
+		}
+	}
+}
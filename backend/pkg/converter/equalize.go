@@ -0,0 +1,240 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Automatic contrast methods
+const (
+	EqualizeNone   = "none"
+	EqualizeGlobal = "global" // Global histogram equalization
+	EqualizeCLAHE  = "clahe"  // Contrast-limited adaptive histogram equalization
+)
+
+// EqualizeOptions controls automatic contrast on grayscale images
+type EqualizeOptions struct {
+	Method    string  // EqualizeNone, EqualizeGlobal or EqualizeCLAHE
+	TileSize  int     // CLAHE tile edge length in pixels
+	ClipLimit float64 // CLAHE clip limit as a multiple of the mean histogram bin height
+	GrayModel string  // Grayscale model for color images (see GrayFunc; default GrayRec601)
+}
+
+// DefaultEqualizeOptions returns no equalization, with CLAHE defaults suited to
+// images that have already been resized to ASCII resolution
+func DefaultEqualizeOptions() EqualizeOptions {
+	return EqualizeOptions{Method: EqualizeNone, TileSize: 16, ClipLimit: 2}
+}
+
+// Validate checks the method, CLAHE parameters and grayscale model
+func (o EqualizeOptions) Validate() error {
+	if err := ValidateGrayModel(o.GrayModel); err != nil {
+		return err
+	}
+	switch o.Method {
+	case "", EqualizeNone, EqualizeGlobal:
+		return nil
+	case EqualizeCLAHE:
+		if o.TileSize < 2 {
+			return fmt.Errorf("CLAHE tile size must be at least 2 pixels")
+		}
+		if o.ClipLimit < 1 {
+			return fmt.Errorf("CLAHE clip limit must be at least 1")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown equalization method %q (valid: %s, %s, %s)", o.Method, EqualizeNone, EqualizeGlobal, EqualizeCLAHE)
+	}
+}
+
+// Histogram counts pixels per brightness level
+type Histogram [256]int
+
+// Add accumulates the brightness of every pixel of img. Adding several frames
+// builds a histogram for a whole clip. Colors are reduced with RGBToGrayScale; use
+// ConvertToGrayscaleModel first for another grayscale model.
+func (h *Histogram) Add(img image.Image) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			h[grayAt(img, x, y)]++
+		}
+	}
+}
+
+// EqualizationTable returns the brightness mapping that spreads the histogram evenly
+// over 0-255, based on its cumulative distribution
+func (h *Histogram) EqualizationTable() [256]uint8 {
+	var table [256]uint8
+
+	total, cdfMin, cumulative := 0, 0, 0
+	for _, count := range h {
+		total += count
+	}
+	for _, count := range h {
+		if count > 0 {
+			cdfMin = count
+			break
+		}
+	}
+	if total == cdfMin {
+		// Flat image: nothing to spread, keep values as they are
+		for i := range table {
+			table[i] = uint8(i)
+		}
+		return table
+	}
+
+	for i, count := range h {
+		cumulative += count
+		v := float64(cumulative-cdfMin) / float64(total-cdfMin) * 255
+		if v < 0 {
+			v = 0
+		}
+		table[i] = uint8(v + 0.5)
+	}
+	return table
+}
+
+// ApplyToneTable maps every pixel's brightness through table and returns a grayscale image
+func ApplyToneTable(img image.Image, table [256]uint8) *image.Gray {
+	bounds := img.Bounds()
+	out := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			out.SetGray(x, y, color.Gray{Y: table[grayAt(img, x, y)]})
+		}
+	}
+	return out
+}
+
+// EqualizeHistogram applies global histogram equalization to a grayscale image
+func EqualizeHistogram(img image.Image) *image.Gray {
+	var h Histogram
+	h.Add(img)
+	return ApplyToneTable(img, h.EqualizationTable())
+}
+
+// ApplyCLAHE applies contrast-limited adaptive histogram equalization. The image is
+// split into tileSize x tileSize tiles, each tile's histogram is clipped at clipLimit times
+// its mean bin height (the excess is spread evenly over all bins) and equalized, and each
+// pixel is bilinearly interpolated between the mappings of its four nearest tiles.
+func ApplyCLAHE(img *image.Gray, tileSize int, clipLimit float64) *image.Gray {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	tilesX := (width + tileSize - 1) / tileSize
+	tilesY := (height + tileSize - 1) / tileSize
+
+	// Build one mapping per tile
+	tables := make([][256]uint8, tilesX*tilesY)
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			tile := image.Rect(
+				bounds.Min.X+tx*tileSize, bounds.Min.Y+ty*tileSize,
+				bounds.Min.X+(tx+1)*tileSize, bounds.Min.Y+(ty+1)*tileSize,
+			).Intersect(bounds)
+			tables[ty*tilesX+tx] = clippedTileTable(img, tile, clipLimit)
+		}
+	}
+
+	// Position of pixel p along one axis relative to tile centres: the lower tile index
+	// and the weight of the upper tile
+	tilePosition := func(p, tiles int) (int, int, float64) {
+		f := (float64(p)+0.5)/float64(tileSize) - 0.5
+		if f <= 0 {
+			return 0, 0, 0
+		}
+		lower := int(f)
+		if lower >= tiles-1 {
+			return tiles - 1, tiles - 1, 0
+		}
+		return lower, lower + 1, f - float64(lower)
+	}
+
+	out := image.NewGray(bounds)
+	for y := 0; y < height; y++ {
+		y0, y1, wy := tilePosition(y, tilesY)
+		for x := 0; x < width; x++ {
+			x0, x1, wx := tilePosition(x, tilesX)
+			v := grayAt(img, bounds.Min.X+x, bounds.Min.Y+y)
+
+			top := (1-wx)*float64(tables[y0*tilesX+x0][v]) + wx*float64(tables[y0*tilesX+x1][v])
+			bottom := (1-wx)*float64(tables[y1*tilesX+x0][v]) + wx*float64(tables[y1*tilesX+x1][v])
+			out.SetGray(bounds.Min.X+x, bounds.Min.Y+y, color.Gray{Y: uint8((1-wy)*top + wy*bottom + 0.5)})
+		}
+	}
+	return out
+}
+
+// clippedTileTable computes the contrast-limited equalization mapping of one tile
+func clippedTileTable(img *image.Gray, tile image.Rectangle, clipLimit float64) [256]uint8 {
+	var h Histogram
+	h.Add(img.SubImage(tile))
+
+	pixels := tile.Dx() * tile.Dy()
+	limit := int(clipLimit * float64(pixels) / 256)
+	if limit < 1 {
+		limit = 1
+	}
+
+	excess := 0
+	for i, count := range h {
+		if count > limit {
+			excess += count - limit
+			h[i] = limit
+		}
+	}
+	share, remainder := excess/256, excess%256
+	for i := range h {
+		h[i] += share
+	}
+	// Spread what is left evenly over the range rather than onto the darkest bins, which
+	// would push small tiles (less than 256 pixels) towards white
+	if remainder > 0 {
+		step := 256 / remainder
+		for i := 0; i < 256 && remainder > 0; i += step {
+			h[i]++
+			remainder--
+		}
+	}
+
+	// Standard CLAHE maps through the plain CDF so flat tiles are not stretched
+	var table [256]uint8
+	cumulative := 0
+	for i, count := range h {
+		cumulative += count
+		table[i] = uint8(float64(cumulative)*255/float64(pixels) + 0.5)
+	}
+	return table
+}
+
+// Equalize applies the selected automatic contrast method to an image, converting
+// it to grayscale with opts.GrayModel first. With EqualizeNone the image is returned unchanged.
+func Equalize(img image.Image, opts EqualizeOptions) image.Image {
+	switch opts.Method {
+	case EqualizeGlobal:
+		return EqualizeHistogram(toGray(img, opts.GrayModel))
+	case EqualizeCLAHE:
+		return ApplyCLAHE(toGray(img, opts.GrayModel), opts.TileSize, opts.ClipLimit)
+	default:
+		return img
+	}
+}
+
+// toGray returns img as *image.Gray, converting it with a grayscale model if necessary
+func toGray(img image.Image, model string) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray
+	}
+	return convertToGrayscale(img, GrayFunc(model))
+}
+
+// grayAt returns the brightness of the pixel at (x, y)
+func grayAt(img image.Image, x, y int) uint8 {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray.GrayAt(x, y).Y
+	}
+	r, g, b, _ := img.At(x, y).RGBA()
+	return RGBToGrayScale(r, g, b)
+}
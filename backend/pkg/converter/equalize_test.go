@@ -0,0 +1,125 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEqualizeOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    EqualizeOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: DefaultEqualizeOptions()},
+		{name: "empty method", opts: EqualizeOptions{}},
+		{name: "global", opts: EqualizeOptions{Method: EqualizeGlobal, GrayModel: GrayLinear}},
+		{name: "clahe", opts: EqualizeOptions{Method: EqualizeCLAHE, TileSize: 2, ClipLimit: 1}},
+		{name: "clahe tile too small", opts: EqualizeOptions{Method: EqualizeCLAHE, TileSize: 1, ClipLimit: 2}, wantErr: true},
+		{name: "clahe clip too small", opts: EqualizeOptions{Method: EqualizeCLAHE, TileSize: 16, ClipLimit: 0.5}, wantErr: true},
+		{name: "unknown method", opts: EqualizeOptions{Method: "auto"}, wantErr: true},
+		{name: "unknown gray model", opts: EqualizeOptions{Method: EqualizeGlobal, GrayModel: "luma"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEqualizationTable(t *testing.T) {
+	var flat Histogram
+	flat[77] = 100
+	table := flat.EqualizationTable()
+	for i, v := range table {
+		if int(v) != i {
+			t.Fatalf("flat histogram: table[%d] = %d, want identity", i, v)
+		}
+	}
+
+	// Two equally common levels spread to the ends of the range
+	var split Histogram
+	split[100], split[110] = 50, 50
+	table = split.EqualizationTable()
+	if table[100] != 0 || table[110] != 255 {
+		t.Errorf("split histogram: got %d and %d, want 0 and 255", table[100], table[110])
+	}
+}
+
+// Equalize must reduce colors with the configured grayscale model
+func TestEqualizeGrayModel(t *testing.T) {
+	// Red and green halves that Rec.601 sees as different levels but the blue channel does not
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			c := color.NRGBA{R: 200, B: 40, A: 255}
+			if x >= 4 {
+				c = color.NRGBA{G: 200, B: 40, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	tests := []struct {
+		opts        EqualizeOptions
+		left, right uint8
+	}{
+		{opts: EqualizeOptions{Method: EqualizeGlobal}, left: 0, right: 255},
+		{opts: EqualizeOptions{Method: EqualizeGlobal, GrayModel: GrayBlue}, left: 40, right: 40},
+		{opts: EqualizeOptions{Method: EqualizeGlobal, GrayModel: GrayGreen}, left: 0, right: 255},
+		{opts: EqualizeOptions{Method: EqualizeCLAHE, TileSize: 8, ClipLimit: 256, GrayModel: GrayBlue}, left: 255, right: 255},
+	}
+	for _, tt := range tests {
+		gray, ok := Equalize(img, tt.opts).(*image.Gray)
+		if !ok {
+			t.Fatalf("%+v: result is not an *image.Gray", tt.opts)
+		}
+		if left, right := gray.GrayAt(0, 0).Y, gray.GrayAt(7, 0).Y; left != tt.left || right != tt.right {
+			t.Errorf("%+v: got %d and %d, want %d and %d", tt.opts, left, right, tt.left, tt.right)
+		}
+	}
+}
+
+func TestApplyCLAHE(t *testing.T) {
+	// A flat image keeps its level (including in partial tiles), and a low-contrast gradient
+	// gets stretched when the clip limit allows it
+	flat := image.NewGray(image.Rect(0, 0, 40, 24))
+	for i := range flat.Pix {
+		flat.Pix[i] = 100
+	}
+	gradient := image.NewGray(image.Rect(5, 5, 45, 29))
+	for y := 5; y < 29; y++ {
+		for x := 5; x < 45; x++ {
+			gradient.SetGray(x, y, color.Gray{Y: uint8(100 + (x-5)/2)})
+		}
+	}
+
+	tests := []struct {
+		name     string
+		img      *image.Gray
+		clip     float64
+		minRange int
+		maxShift int
+	}{
+		{name: "flat", img: flat, clip: 2, maxShift: 8},
+		{name: "gradient", img: gradient, clip: 40, minRange: 100, maxShift: 255},
+	}
+	for _, tt := range tests {
+		out := ApplyCLAHE(tt.img, 16, tt.clip)
+		if out.Bounds() != tt.img.Bounds() {
+			t.Fatalf("%s: bounds %v, want %v", tt.name, out.Bounds(), tt.img.Bounds())
+		}
+		lo, hi, shift := 255, 0, 0
+		for i, v := range out.Pix {
+			lo, hi = min(lo, int(v)), max(hi, int(v))
+			shift = max(shift, int(v)-int(tt.img.Pix[i]), int(tt.img.Pix[i])-int(v))
+		}
+		if hi-lo < tt.minRange {
+			t.Errorf("%s: output spans %d-%d, want a range of at least %d", tt.name, lo, hi, tt.minRange)
+		}
+		if shift > tt.maxShift {
+			t.Errorf("%s: a pixel moved by %d, want at most %d", tt.name, shift, tt.maxShift)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// exifOrientationTag is the TIFF tag holding the EXIF orientation
+const exifOrientationTag = 0x0112
+
+// jpegOrientation returns the EXIF orientation (1-8) stored in a JPEG file, or 1 when the
+// file has no EXIF block or no orientation tag. Only the segments before the image data
+// are scanned.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker
+			i++
+			continue
+		case marker == 0xD9 || marker == 0xDA:
+			// End of image or start of scan: no metadata follows
+			return 1
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Markers without a length
+			i += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of an EXIF TIFF block,
+// returning 1 when it is missing or out of range
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	// Compare before converting, so a huge offset can't wrap to a negative int
+	offset := order.Uint32(tiff[4:])
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return 1
+	}
+	ifd := int(offset)
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + 12*e
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+		// A SHORT value sits in the first two bytes of the entry's value field
+		if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+			return orientation
+		}
+		return 1
+	}
+	return 1
+}
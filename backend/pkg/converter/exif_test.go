@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// exifTIFF builds an EXIF TIFF block whose first IFD holds one SHORT entry with tag and value
+func exifTIFF(order binary.ByteOrder, tag, value uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], tag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], value)
+	return tiff
+}
+
+// jpegSegment returns a marker segment with its big-endian length
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// jpegWith returns the start of a JPEG file: SOI followed by the given segments
+func jpegWith(segments ...[]byte) []byte {
+	data := []byte{0xFF, 0xD8}
+	for _, s := range segments {
+		data = append(data, s...)
+	}
+	return data
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	exif := func(tiff []byte) []byte {
+		return jpegSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+	}
+	app0 := jpegSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+	sos := []byte{0xFF, 0xDA, 0x00, 0x02}
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "little endian", data: jpegWith(exif(exifTIFF(binary.LittleEndian, exifOrientationTag, 6))), want: 6},
+		{name: "big endian", data: jpegWith(exif(exifTIFF(binary.BigEndian, exifOrientationTag, 3))), want: 3},
+		{name: "after APP0", data: jpegWith(app0, exif(exifTIFF(binary.LittleEndian, exifOrientationTag, 8))), want: 8},
+		{name: "fill bytes", data: jpegWith([]byte{0xFF}, exif(exifTIFF(binary.BigEndian, exifOrientationTag, 5))), want: 5},
+		{name: "not a JPEG", data: exifTIFF(binary.LittleEndian, exifOrientationTag, 6), want: 1},
+		{name: "empty", data: nil, want: 1},
+		{name: "no EXIF", data: jpegWith(app0, sos), want: 1},
+		{name: "EXIF after scan", data: jpegWith(sos, exif(exifTIFF(binary.LittleEndian, exifOrientationTag, 6))), want: 1},
+		{name: "other tag", data: jpegWith(exif(exifTIFF(binary.LittleEndian, 0x0110, 6))), want: 1},
+		{name: "out of range", data: jpegWith(exif(exifTIFF(binary.LittleEndian, exifOrientationTag, 9))), want: 1},
+		{name: "zero orientation", data: jpegWith(exif(exifTIFF(binary.LittleEndian, exifOrientationTag, 0))), want: 1},
+		{name: "XMP APP1", data: jpegWith(jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00"))), want: 1},
+		{name: "truncated segment", data: jpegWith(exif(exifTIFF(binary.LittleEndian, exifOrientationTag, 6)))[:20], want: 1},
+		{name: "short length", data: jpegWith([]byte{0xFF, 0xE1, 0x00, 0x01}, exif(exifTIFF(binary.LittleEndian, exifOrientationTag, 6))), want: 1},
+	}
+	for _, tt := range tests {
+		if got := jpegOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: jpegOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEXIFOrientation(t *testing.T) {
+	valid := exifTIFF(binary.LittleEndian, exifOrientationTag, 7)
+	corrupt := func(edit func(tiff []byte)) []byte {
+		tiff := append([]byte(nil), valid...)
+		edit(tiff)
+		return tiff
+	}
+
+	tests := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{name: "valid", tiff: valid, want: 7},
+		{name: "too short", tiff: valid[:7], want: 1},
+		{name: "unknown byte order", tiff: corrupt(func(b []byte) { copy(b, "XX") }), want: 1},
+		{name: "bad magic", tiff: corrupt(func(b []byte) { b[2] = 43 }), want: 1},
+		{name: "IFD past the end", tiff: corrupt(func(b []byte) { binary.LittleEndian.PutUint32(b[4:], 1000) }), want: 1},
+		{name: "IFD offset overflow", tiff: corrupt(func(b []byte) { binary.LittleEndian.PutUint32(b[4:], 0xFFFFFFFF) }), want: 1},
+		{name: "too many entries", tiff: corrupt(func(b []byte) { binary.LittleEndian.PutUint16(b[8:], 50) }), want: 7},
+		{name: "entries past the end", tiff: corrupt(func(b []byte) {
+			binary.LittleEndian.PutUint16(b[8:], 2)
+			binary.LittleEndian.PutUint16(b[10:], 0x0110)
+		}), want: 1},
+	}
+	for _, tt := range tests {
+		if got := exifOrientation(tt.tiff); got != tt.want {
+			t.Errorf("%s: exifOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,73 +1,209 @@
 package converter
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
-// ConvertToSVG converts ASCII art to an SVG image
-func ConvertToSVG(asciiArt string, coloredASCII *ColoredASCII, fontSize int) string {
-	var svg strings.Builder
-	
-	lines := strings.Split(strings.TrimRight(asciiArt, "\n"), "\n")
-	if coloredASCII != nil {
-		lines = make([]string, len(coloredASCII.Lines))
-		for i, line := range coloredASCII.Lines {
-			var lineStr strings.Builder
-			for _, char := range line {
-				lineStr.WriteString(char.Char)
-			}
-			lines[i] = lineStr.String()
+// embeddedFontFamily is the font-family name of a font embedded with SVGOptions.Font
+const embeddedFontFamily = "ascii-export-font"
+
+// SVGOptions controls how ASCII art is exported as SVG
+type SVGOptions struct {
+	FontFamily    string  // CSS font-family list (default: monospace)
+	FontSize      int     // Font size in pixels
+	CharWidth     float64 // Advance of one character as a multiple of the font size (about 0.6 for most monospace fonts)
+	LineHeight    float64 // Line height as a multiple of the font size
+	LetterSpacing float64 // Extra space after each character in pixels
+	Background    *RGB    // Background color; nil leaves the background transparent
+	Foreground    RGB     // Color of plain text output
+
+	// Font is an optional WOFF2, WOFF, TrueType or OpenType file embedded with @font-face and
+	// used ahead of FontFamily, so the export looks the same without the font installed
+	Font []byte
+}
+
+// DefaultSVGOptions returns white 12px monospace text on black
+func DefaultSVGOptions() SVGOptions {
+	return SVGOptions{
+		FontFamily: "monospace",
+		FontSize:   12,
+		CharWidth:  0.6,
+		LineHeight: 1,
+		Background: &RGB{},
+		Foreground: RGB{R: 255, G: 255, B: 255},
+	}
+}
+
+// Validate checks the font metrics and the embedded font, and that the font family cannot
+// break out of its attribute
+func (o SVGOptions) Validate() error {
+	if o.FontSize <= 0 {
+		return fmt.Errorf("font size must be positive")
+	}
+	if !(o.CharWidth > 0) || math.IsInf(o.CharWidth, 1) {
+		return fmt.Errorf("character width must be positive")
+	}
+	if !(o.LineHeight > 0) || math.IsInf(o.LineHeight, 1) {
+		return fmt.Errorf("line height must be positive")
+	}
+	if math.IsNaN(o.LetterSpacing) || math.IsInf(o.LetterSpacing, 0) {
+		return fmt.Errorf("letter spacing must be a finite number")
+	}
+	if o.CharWidth*float64(o.FontSize)+o.LetterSpacing <= 0 {
+		return fmt.Errorf("letter spacing %g leaves no room for the characters", o.LetterSpacing)
+	}
+	if strings.ContainsAny(o.FontFamily, ";{}<>\"\\") {
+		return fmt.Errorf("invalid font family %q", o.FontFamily)
+	}
+	if len(o.Font) > 0 {
+		if _, _, err := fontFormat(o.Font); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fontFormat returns the @font-face format and MIME type of a font file from its signature
+func fontFormat(data []byte) (format, mimeType string, err error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("wOF2")):
+		return "woff2", "font/woff2", nil
+	case bytes.HasPrefix(data, []byte("wOFF")):
+		return "woff", "font/woff", nil
+	case bytes.HasPrefix(data, []byte("OTTO")):
+		return "opentype", "font/otf", nil
+	case bytes.HasPrefix(data, []byte{0, 1, 0, 0}), bytes.HasPrefix(data, []byte("true")):
+		return "truetype", "font/ttf", nil
+	}
+	return "", "", fmt.Errorf("unsupported font file (expected WOFF2, WOFF, TrueType or OpenType)")
+}
+
+// ConvertToSVG converts ASCII art to an SVG image. Every character takes one cell of
+// opts.CharWidth x opts.LineHeight font sizes plus the letter spacing, so widths are
+// measured in characters rather than bytes. Each line is one <text> element stretched to
+// its cell width, so glyphs stay on the cell grid whatever the viewer's font; colored
+// output is split into <tspan> runs of the same color, and background colors are drawn as
+// one rectangle per run of equal cells. Transparent cells are left unpainted.
+func ConvertToSVG(asciiArt string, coloredASCII *ColoredASCII, opts SVGOptions) string {
+	var plain []string
+	if coloredASCII == nil {
+		if trimmed := strings.TrimRight(asciiArt, "\n"); trimmed != "" {
+			plain = strings.Split(trimmed, "\n")
 		}
 	}
-	
-	if len(lines) == 0 {
+	rows := len(plain)
+	if coloredASCII != nil {
+		rows = len(coloredASCII.Lines)
+	}
+	if rows == 0 {
 		return ""
 	}
-	
-	// Find the maximum line width (in characters)
-	maxWidth := 0
-	for _, line := range lines {
-		if len(line) > maxWidth {
-			maxWidth = len(line)
+
+	// lineColumns returns the width of line y in characters
+	lineColumns := func(y int) int {
+		if coloredASCII != nil {
+			return len(coloredASCII.Lines[y])
 		}
+		return utf8.RuneCountInString(plain[y])
 	}
-	
-	// Calculate dimensions based on actual content
-	charWidth := fontSize * 6 / 10 // Approximate character width (0.6 * fontSize)
-	width := maxWidth * charWidth
-	height := len(lines) * fontSize
-	
-	svg.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" style="background:black;">`, width, height))
+	columns := 0
+	for y := 0; y < rows; y++ {
+		columns = max(columns, lineColumns(y))
+	}
+
+	fontSize := float64(opts.FontSize)
+	advance := opts.CharWidth*fontSize + opts.LetterSpacing
+	lineHeight := opts.LineHeight * fontSize
+	// Centre the em box in the line, with the baseline about 0.8 em below its top
+	baseline := (lineHeight-fontSize)/2 + 0.8*fontSize
+
+	var svg strings.Builder
+	width, height := svgNumber(float64(columns)*advance), svgNumber(float64(rows)*lineHeight)
+	svg.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" xml:space="preserve">`,
+		width, height, width, height))
 	svg.WriteString("\n")
-	
+
+	fontFamily := opts.FontFamily
+	if fontFamily == "" {
+		fontFamily = "monospace"
+	}
+	if len(opts.Font) > 0 {
+		format, mimeType, _ := fontFormat(opts.Font)
+		svg.WriteString(fmt.Sprintf(`<defs><style>@font-face{font-family:"%s";src:url(data:%s;base64,%s) format("%s")}</style></defs>`,
+			embeddedFontFamily, mimeType, base64.StdEncoding.EncodeToString(opts.Font), format))
+		svg.WriteString("\n")
+		fontFamily = embeddedFontFamily + ", " + fontFamily
+	}
+
+	if opts.Background != nil {
+		svg.WriteString(fmt.Sprintf(`<rect width="100%%" height="100%%" fill="%s"/>`, cssColor(*opts.Background)))
+		svg.WriteString("\n")
+	}
+
+	// Cell backgrounds (half blocks), merged along each line
 	if coloredASCII != nil {
-		// Render colored ASCII
-		y := fontSize
-		for _, line := range coloredASCII.Lines {
-			x := 0
-			for _, char := range line {
-				rgb := fmt.Sprintf("rgb(%d,%d,%d)", char.R, char.G, char.B)
-				svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" fill="%s" font-family="monospace" font-size="%d">%s</text>`, 
-					x, y, rgb, fontSize, escapeXML(char.Char)))
-				x += fontSize * 6 / 10
+		svg.WriteString(`<g shape-rendering="crispEdges">`)
+		cellBackground := func(char ColoredChar) *RGB {
+			if char.Transparent {
+				return nil
 			}
-			y += fontSize
+			return char.Background
 		}
-	} else {
-		// Render grayscale ASCII
-		y := fontSize
-		for _, line := range lines {
-			svg.WriteString(fmt.Sprintf(`<text x="0" y="%d" fill="white" font-family="monospace" font-size="%d">%s</text>`, 
-				y, fontSize, escapeXML(line)))
-			y += fontSize
+		for y, line := range coloredASCII.Lines {
+			for start := 0; start < len(line); {
+				bg := cellBackground(line[start])
+				end := start + 1
+				for end < len(line) && sameBackground(cellBackground(line[end]), bg) {
+					end++
+				}
+				if bg != nil {
+					svg.WriteString(fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`,
+						svgNumber(float64(start)*advance), svgNumber(float64(y)*lineHeight),
+						svgNumber(float64(end-start)*advance), svgNumber(lineHeight), cssColor(*bg)))
+				}
+				start = end
+			}
 		}
+		svg.WriteString("</g>\n")
 	}
-	
-	svg.WriteString("\n</svg>")
+
+	svg.WriteString(fmt.Sprintf(`<g font-family="%s" font-size="%d" fill="%s">`, escapeXML(fontFamily), opts.FontSize, cssColor(opts.Foreground)))
+	svg.WriteString("\n")
+	for y := 0; y < rows; y++ {
+		lineWidth := lineColumns(y)
+		if lineWidth == 0 {
+			continue
+		}
+		svg.WriteString(fmt.Sprintf(`<text x="0" y="%s" textLength="%s">`,
+			svgNumber(float64(y)*lineHeight+baseline), svgNumber(float64(lineWidth)*advance)))
+		if coloredASCII == nil {
+			svg.WriteString(escapeXML(plain[y]))
+		} else {
+			for _, run := range textRuns(coloredASCII.Lines[y], false) {
+				if !run.styled {
+					svg.WriteString(escapeXML(run.text.String()))
+					continue
+				}
+				svg.WriteString(fmt.Sprintf(`<tspan fill="%s">%s</tspan>`, cssColor(run.color), escapeXML(run.text.String())))
+			}
+		}
+		svg.WriteString("</text>\n")
+	}
+	svg.WriteString("</g>\n</svg>")
 	return svg.String()
 }
 
+// svgNumber formats a coordinate with at most two decimals
+func svgNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
 // Helper function to escape XML special characters
 func escapeXML(s string) string {
 	s = strings.ReplaceAll(s, "&", "&amp;")
@@ -77,4 +213,3 @@ func escapeXML(s string) string {
 	s = strings.ReplaceAll(s, "'", "&apos;")
 	return s
 }
-
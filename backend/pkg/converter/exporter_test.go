@@ -0,0 +1,116 @@
+package converter
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSVGOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*SVGOptions)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(o *SVGOptions) {}},
+		{name: "negative spacing", modify: func(o *SVGOptions) { o.LetterSpacing = -2 }},
+		{name: "woff2 font", modify: func(o *SVGOptions) { o.Font = []byte("wOF2 font data") }},
+		{name: "truetype font", modify: func(o *SVGOptions) { o.Font = []byte{0, 1, 0, 0, 9} }},
+		{name: "zero font size", modify: func(o *SVGOptions) { o.FontSize = 0 }, wantErr: true},
+		{name: "zero char width", modify: func(o *SVGOptions) { o.CharWidth = 0 }, wantErr: true},
+		{name: "NaN char width", modify: func(o *SVGOptions) { o.CharWidth = math.NaN() }, wantErr: true},
+		{name: "infinite line height", modify: func(o *SVGOptions) { o.LineHeight = math.Inf(1) }, wantErr: true},
+		{name: "negative line height", modify: func(o *SVGOptions) { o.LineHeight = -1 }, wantErr: true},
+		{name: "NaN spacing", modify: func(o *SVGOptions) { o.LetterSpacing = math.NaN() }, wantErr: true},
+		{name: "spacing eats the cell", modify: func(o *SVGOptions) { o.LetterSpacing = -7.2 }, wantErr: true},
+		{name: "attribute break", modify: func(o *SVGOptions) { o.FontFamily = `mono"/><script>` }, wantErr: true},
+		{name: "declaration break", modify: func(o *SVGOptions) { o.FontFamily = "mono;}" }, wantErr: true},
+		{name: "unknown font file", modify: func(o *SVGOptions) { o.Font = []byte("<svg>") }, wantErr: true},
+	}
+	for _, tt := range tests {
+		opts := DefaultSVGOptions()
+		tt.modify(&opts)
+		if err := opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEscapeXML(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{in: "plain", want: "plain"},
+		{in: `<a href="x">&'</a>`, want: "&lt;a href=&quot;x&quot;&gt;&amp;&apos;&lt;/a&gt;"},
+		{in: "&amp;", want: "&amp;amp;"},
+		{in: "█▓ é", want: "█▓ é"},
+	}
+	for _, tt := range tests {
+		if got := escapeXML(tt.in); got != tt.want {
+			t.Errorf("escapeXML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvertToSVG(t *testing.T) {
+	red := RGB{R: 255}
+	transparent := DefaultSVGOptions()
+	transparent.Background = nil
+	embedded := DefaultSVGOptions()
+	embedded.Font = []byte("wOF2")
+
+	tests := []struct {
+		name     string
+		text     string
+		colored  *ColoredASCII
+		opts     SVGOptions
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "plain text",
+			text: "<█&\n>\n",
+			opts: DefaultSVGOptions(),
+			contains: []string{
+				`width="21.6" height="24" viewBox="0 0 21.6 24"`,
+				`<rect width="100%" height="100%" fill="#000000"/>`,
+				`font-family="monospace" font-size="12" fill="#ffffff"`,
+				`<text x="0" y="9.6" textLength="21.6">&lt;█&amp;</text>`,
+				`<text x="0" y="21.6" textLength="7.2">&gt;</text>`,
+			},
+		},
+		{
+			name: "colored",
+			colored: &ColoredASCII{Lines: [][]ColoredChar{{
+				{Char: "a", G: 255, Background: &red}, {Char: "b", G: 255, Background: &red}, {Char: " ", Transparent: true}, {Char: "'", B: 255},
+			}}},
+			opts: transparent,
+			contains: []string{
+				`<rect x="0" y="0" width="14.4" height="12" fill="#ff0000"/>`,
+				`<tspan fill="#00ff00">ab </tspan><tspan fill="#0000ff">&apos;</tspan>`,
+			},
+			excludes: []string{`<rect width="100%"`},
+		},
+		{
+			name:     "embedded font",
+			text:     "x",
+			opts:     embedded,
+			contains: []string{`@font-face{font-family:"ascii-export-font";src:url(data:font/woff2;base64,d09GMg==) format("woff2")}`, `font-family="ascii-export-font, monospace"`},
+		},
+	}
+	for _, tt := range tests {
+		got := ConvertToSVG(tt.text, tt.colored, tt.opts)
+		for _, want := range tt.contains {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: output lacks %s\n%s", tt.name, want, got)
+			}
+		}
+		for _, unwanted := range tt.excludes {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("%s: output contains %s", tt.name, unwanted)
+			}
+		}
+	}
+
+	if got := ConvertToSVG("\n\n", nil, DefaultSVGOptions()); got != "" {
+		t.Errorf("empty art: got %q, want no SVG", got)
+	}
+}
@@ -0,0 +1,348 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Spatial filters
+const (
+	FilterMedian    = "median"    // Median of a square window; removes speckle noise
+	FilterGaussian  = "gaussian"  // Gaussian blur
+	FilterBilateral = "bilateral" // Edge-preserving denoise
+	FilterSharpen   = "sharpen"   // Unsharp mask
+)
+
+// Filter stages
+const (
+	FilterStageBefore = "before" // Filter the source image before ResizeImage
+	FilterStageAfter  = "after"  // Filter the resized image (much cheaper)
+)
+
+// Filter is one spatial filter stage. Params holds the filter's numeric parameters in order:
+//   - median: radius (default 1)
+//   - gaussian: sigma (default 1)
+//   - bilateral: spatial sigma, range sigma in brightness units (defaults 2, 30)
+//   - sharpen: sigma, amount (defaults 1, 1)
+type Filter struct {
+	Name   string
+	Params []float64
+}
+
+// FilterChain is an ordered list of filters applied at one stage of the pipeline
+type FilterChain struct {
+	Filters []Filter
+	Stage   string // FilterStageBefore or FilterStageAfter (default)
+}
+
+// ParseFilterChain parses a comma-separated filter list such as "median:1,sharpen:1:0.8".
+// Each entry is a filter name optionally followed by colon-separated parameters.
+func ParseFilterChain(spec string) ([]Filter, error) {
+	var filters []Filter
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		filter := Filter{Name: strings.ToLower(parts[0])}
+		for _, p := range parts[1:] {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parameter %q for filter %s", p, filter.Name)
+			}
+			filter.Params = append(filter.Params, v)
+		}
+		if err := filter.Validate(); err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// MaxFilterPixels is the largest image filtered before resizing. Larger sources are scaled
+// down to fit first, since a wide median or bilateral window over a full-size photo could
+// otherwise run for minutes.
+const MaxFilterPixels = 4_000_000
+
+// filterLimits holds the largest accepted value of each filter's parameters, in order. They
+// bound the median window and the Gaussian and bilateral kernels, whose radius grows with sigma.
+var filterLimits = map[string][]float64{
+	FilterMedian:    {10},
+	FilterGaussian:  {20},
+	FilterBilateral: {20, 255},
+	FilterSharpen:   {20, 10},
+}
+
+// Validate checks the filter name, parameter count and parameter ranges
+func (f Filter) Validate() error {
+	limits, ok := filterLimits[f.Name]
+	if !ok {
+		return fmt.Errorf("unknown filter %q (valid: %s, %s, %s, %s)", f.Name, FilterMedian, FilterGaussian, FilterBilateral, FilterSharpen)
+	}
+	if len(f.Params) > len(limits) {
+		return fmt.Errorf("filter %s takes at most %d parameters", f.Name, len(limits))
+	}
+	for i, p := range f.Params {
+		if math.IsNaN(p) || math.IsInf(p, 0) || p <= 0 {
+			return fmt.Errorf("filter %s parameters must be positive", f.Name)
+		}
+		if p > limits[i] {
+			return fmt.Errorf("filter %s parameter %d must be at most %g", f.Name, i+1, limits[i])
+		}
+	}
+	if f.Name == FilterMedian && len(f.Params) > 0 && f.Params[0] < 1 {
+		return fmt.Errorf("filter %s radius must be at least 1", f.Name)
+	}
+	return nil
+}
+
+// Validate checks the stage and every filter in the chain
+func (c FilterChain) Validate() error {
+	switch c.Stage {
+	case "", FilterStageBefore, FilterStageAfter:
+	default:
+		return fmt.Errorf("unknown filter stage %q (valid: %s, %s)", c.Stage, FilterStageBefore, FilterStageAfter)
+	}
+	for _, f := range c.Filters {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeforeResize applies the chain when it is configured to run on the source image. A source
+// larger than MaxFilterPixels is first scaled down to fit, keeping its aspect ratio.
+func (c FilterChain) BeforeResize(img image.Image) image.Image {
+	if c.Stage != FilterStageBefore || len(c.Filters) == 0 {
+		return img
+	}
+	bounds := img.Bounds()
+	if pixels := bounds.Dx() * bounds.Dy(); pixels > MaxFilterPixels {
+		scale := math.Sqrt(float64(MaxFilterPixels) / float64(pixels))
+		width := max(int(float64(bounds.Dx())*scale), 1)
+		height := max(int(float64(bounds.Dy())*scale), 1)
+		img = Resample(img, width, height, ResampleBox)
+	}
+	return ApplyFilters(img, c.Filters)
+}
+
+// AfterResize applies the chain when it is configured to run on the resized image
+func (c FilterChain) AfterResize(img image.Image) image.Image {
+	if c.Stage == FilterStageBefore {
+		return img
+	}
+	return ApplyFilters(img, c.Filters)
+}
+
+// param returns the i-th parameter or def when it was not given
+func (f Filter) param(i int, def float64) float64 {
+	if i < len(f.Params) {
+		return f.Params[i]
+	}
+	return def
+}
+
+// ApplyFilters runs the filters in order. Each filter works on the RGB channels and
+// preserves alpha. With no filters the image is returned unchanged.
+func ApplyFilters(img image.Image, filters []Filter) image.Image {
+	if len(filters) == 0 {
+		return img
+	}
+
+	out := toNRGBA(img)
+	for _, f := range filters {
+		switch f.Name {
+		case FilterMedian:
+			out = medianFilter(out, int(f.param(0, 1)))
+		case FilterGaussian:
+			out = gaussianBlur(out, f.param(0, 1))
+		case FilterBilateral:
+			out = bilateralFilter(out, f.param(0, 2), f.param(1, 30))
+		case FilterSharpen:
+			out = unsharpMask(out, f.param(0, 1), f.param(1, 1))
+		}
+	}
+	return out
+}
+
+// toNRGBA copies img into a zero-origin *image.NRGBA for direct pixel access
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			out.SetNRGBA(x-bounds.Min.X, y-bounds.Min.Y, color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA))
+		}
+	}
+	return out
+}
+
+// clampCoord limits v to [0, n-1] so windows replicate the border pixels
+func clampCoord(v, n int) int {
+	if v < 0 {
+		return 0
+	}
+	if v >= n {
+		return n - 1
+	}
+	return v
+}
+
+func clampChannel(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(v))))
+}
+
+// medianFilter replaces each channel value with the median of its (2r+1)^2 neighbourhood
+func medianFilter(img *image.NRGBA, radius int) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewNRGBA(img.Rect)
+
+	parallelRows(0, h, func(_, y0, y1 int) {
+		window := make([]uint8, 0, (2*radius+1)*(2*radius+1))
+		for y := y0; y < y1; y++ {
+			for x := 0; x < w; x++ {
+				o := out.PixOffset(x, y)
+				for ch := 0; ch < 3; ch++ {
+					window = window[:0]
+					for dy := -radius; dy <= radius; dy++ {
+						for dx := -radius; dx <= radius; dx++ {
+							window = append(window, img.Pix[img.PixOffset(clampCoord(x+dx, w), clampCoord(y+dy, h))+ch])
+						}
+					}
+					sort.Slice(window, func(i, j int) bool { return window[i] < window[j] })
+					out.Pix[o+ch] = window[len(window)/2]
+				}
+				out.Pix[o+3] = img.Pix[img.PixOffset(x, y)+3]
+			}
+		}
+	})
+	return out
+}
+
+// gaussianKernel returns a normalized 1D Gaussian kernel covering +-3 sigma
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// gaussianBlur blurs the RGB channels with a separable Gaussian kernel
+func gaussianBlur(img *image.NRGBA, sigma float64) *image.NRGBA {
+	kernel := gaussianKernel(sigma)
+	radius := len(kernel) / 2
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+
+	// Horizontal pass into a float buffer, then vertical pass into the output
+	tmp := make([]float64, w*h*3)
+	parallelRows(0, h, func(_, y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := 0; x < w; x++ {
+				for ch := 0; ch < 3; ch++ {
+					sum := 0.0
+					for k, weight := range kernel {
+						sum += weight * float64(img.Pix[img.PixOffset(clampCoord(x+k-radius, w), y)+ch])
+					}
+					tmp[(y*w+x)*3+ch] = sum
+				}
+			}
+		}
+	})
+
+	out := image.NewNRGBA(img.Rect)
+	parallelRows(0, h, func(_, y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := 0; x < w; x++ {
+				o := out.PixOffset(x, y)
+				for ch := 0; ch < 3; ch++ {
+					sum := 0.0
+					for k, weight := range kernel {
+						sum += weight * tmp[(clampCoord(y+k-radius, h)*w+x)*3+ch]
+					}
+					out.Pix[o+ch] = clampChannel(sum)
+				}
+				out.Pix[o+3] = img.Pix[o+3]
+			}
+		}
+	})
+	return out
+}
+
+// bilateralFilter smooths flat areas while keeping edges: each neighbour is weighted by
+// its distance (spatialSigma, in pixels) and by its color difference (rangeSigma)
+func bilateralFilter(img *image.NRGBA, spatialSigma, rangeSigma float64) *image.NRGBA {
+	radius := int(math.Ceil(2 * spatialSigma))
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewNRGBA(img.Rect)
+
+	spatial := make([]float64, (2*radius+1)*(2*radius+1))
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			spatial[(dy+radius)*(2*radius+1)+dx+radius] = math.Exp(-float64(dx*dx+dy*dy) / (2 * spatialSigma * spatialSigma))
+		}
+	}
+	rangeDenom := 2 * rangeSigma * rangeSigma
+
+	parallelRows(0, h, func(_, y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := 0; x < w; x++ {
+				c := img.PixOffset(x, y)
+				var sum [3]float64
+				total := 0.0
+				for dy := -radius; dy <= radius; dy++ {
+					for dx := -radius; dx <= radius; dx++ {
+						n := img.PixOffset(clampCoord(x+dx, w), clampCoord(y+dy, h))
+						dist := 0.0
+						for ch := 0; ch < 3; ch++ {
+							d := float64(img.Pix[n+ch]) - float64(img.Pix[c+ch])
+							dist += d * d
+						}
+						weight := spatial[(dy+radius)*(2*radius+1)+dx+radius] * math.Exp(-dist/rangeDenom)
+						for ch := 0; ch < 3; ch++ {
+							sum[ch] += weight * float64(img.Pix[n+ch])
+						}
+						total += weight
+					}
+				}
+				for ch := 0; ch < 3; ch++ {
+					out.Pix[c+ch] = clampChannel(sum[ch] / total)
+				}
+				out.Pix[c+3] = img.Pix[c+3]
+			}
+		}
+	})
+	return out
+}
+
+// unsharpMask sharpens by adding amount times the difference between the image and
+// its Gaussian blur
+func unsharpMask(img *image.NRGBA, sigma, amount float64) *image.NRGBA {
+	blurred := gaussianBlur(img, sigma)
+	out := image.NewNRGBA(img.Rect)
+	parallelRows(0, img.Rect.Dy(), func(_, y0, y1 int) {
+		for i := y0 * img.Stride; i < y1*img.Stride; i += 4 {
+			for ch := 0; ch < 3; ch++ {
+				orig := float64(img.Pix[i+ch])
+				out.Pix[i+ch] = clampChannel(orig + amount*(orig-float64(blurred.Pix[i+ch])))
+			}
+			out.Pix[i+3] = img.Pix[i+3]
+		}
+	})
+	return out
+}
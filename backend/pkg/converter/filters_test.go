@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseFilterChain(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []Filter
+		wantErr bool
+	}{
+		{spec: "", want: nil},
+		{spec: "median", want: []Filter{{Name: FilterMedian}}},
+		{spec: "Median:2, sharpen:1:0.8", want: []Filter{{Name: FilterMedian, Params: []float64{2}}, {Name: FilterSharpen, Params: []float64{1, 0.8}}}},
+		{spec: "gaussian:1.5,,bilateral:2:30", want: []Filter{{Name: FilterGaussian, Params: []float64{1.5}}, {Name: FilterBilateral, Params: []float64{2, 30}}}},
+		{spec: "median:10", want: []Filter{{Name: FilterMedian, Params: []float64{10}}}},
+		{spec: "blur", wantErr: true},
+		{spec: "median:x", wantErr: true},
+		{spec: "median:0.5", wantErr: true},
+		{spec: "median:11", wantErr: true},
+		{spec: "median:1:2", wantErr: true},
+		{spec: "gaussian:0", wantErr: true},
+		{spec: "gaussian:-1", wantErr: true},
+		{spec: "gaussian:NaN", wantErr: true},
+		{spec: "gaussian:Inf", wantErr: true},
+		{spec: "gaussian:21", wantErr: true},
+		{spec: "bilateral:2:256", wantErr: true},
+		{spec: "sharpen:1:11", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFilterChain(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFilterChain(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFilterChain(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestFilterChainValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		chain   FilterChain
+		wantErr bool
+	}{
+		{name: "empty", chain: FilterChain{}},
+		{name: "before", chain: FilterChain{Filters: []Filter{{Name: FilterMedian}}, Stage: FilterStageBefore}},
+		{name: "after", chain: FilterChain{Filters: []Filter{{Name: FilterGaussian}}, Stage: FilterStageAfter}},
+		{name: "unknown stage", chain: FilterChain{Stage: "during"}, wantErr: true},
+		{name: "invalid filter", chain: FilterChain{Filters: []Filter{{Name: FilterMedian, Params: []float64{0.9}}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.chain.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// The row bands must not change any filter's output
+func TestApplyFiltersParallel(t *testing.T) {
+	src := testSources(67, 53)[1].img // NRGBA with partial alpha
+	for _, f := range []Filter{
+		{Name: FilterMedian, Params: []float64{2}},
+		{Name: FilterGaussian, Params: []float64{1.5}},
+		{Name: FilterBilateral},
+		{Name: FilterSharpen, Params: []float64{1, 2}},
+	} {
+		var want, got image.Image
+		func() {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+			want = ApplyFilters(src, []Filter{f})
+		}()
+		func() {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+			got = ApplyFilters(src, []Filter{f})
+		}()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: parallel output differs from a single band", f.Name)
+		}
+	}
+}
+
+func TestBeforeResizeLimit(t *testing.T) {
+	chain := FilterChain{Filters: []Filter{{Name: FilterGaussian, Params: []float64{0.3}}}, Stage: FilterStageBefore}
+	tests := []struct {
+		name          string
+		chain         FilterChain
+		width, height int
+		maxPixels     int
+	}{
+		{name: "small source", chain: chain, width: 300, height: 200, maxPixels: 300 * 200},
+		{name: "large source", chain: chain, width: 4000, height: 1500, maxPixels: MaxFilterPixels},
+		{name: "after stage", chain: FilterChain{Filters: chain.Filters}, width: 4000, height: 1500, maxPixels: 4000 * 1500},
+	}
+	for _, tt := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+		bounds := tt.chain.BeforeResize(img).Bounds()
+		if pixels := bounds.Dx() * bounds.Dy(); pixels > tt.maxPixels || pixels < tt.maxPixels*99/100 {
+			t.Errorf("%s: filtered %v (%d pixels), want about %d pixels", tt.name, bounds.Size(), pixels, tt.maxPixels)
+		}
+		ratio, want := float64(bounds.Dx())/float64(bounds.Dy()), float64(tt.width)/float64(tt.height)
+		if math.Abs(ratio-want) > 0.01*want {
+			t.Errorf("%s: aspect ratio %.3f, want %.3f", tt.name, ratio, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"sync"
+
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Cell size of the embedded bitmap font, in pixels
+const (
+	FontCellWidth  = 7
+	FontCellHeight = 13
+)
+
+// GlyphBitmap is a FontCellWidth x FontCellHeight coverage mask for one character.
+// Values are 0 (background) to 255 (fully inked), stored row by row.
+type GlyphBitmap [FontCellWidth * FontCellHeight]uint8
+
+// At returns the coverage of the pixel at (x, y) within the cell
+func (g *GlyphBitmap) At(x, y int) uint8 {
+	return g[y*FontCellWidth+x]
+}
+
+// glyphCache holds rasterized glyphs keyed by rune
+var glyphCache sync.Map
+
+// RasterizeGlyph returns the bitmap for r using the embedded 7x13 font, so output looks the
+// same everywhere and no system fonts are needed. Block elements (█ ▀ ▄ ░ ▒ ▓) and Braille
+// patterns are drawn procedurally because the font only covers printable ASCII.
+// ok is false when the rune has no glyph; the returned bitmap is then blank.
+func RasterizeGlyph(r rune) (bitmap *GlyphBitmap, ok bool) {
+	if cached, found := glyphCache.Load(r); found {
+		entry := cached.(glyphEntry)
+		return entry.bitmap, entry.ok
+	}
+
+	bitmap = &GlyphBitmap{}
+	ok = rasterizeBlockElement(r, bitmap) || rasterizeBraille(r, bitmap) || rasterizeFontGlyph(r, bitmap)
+	glyphCache.Store(r, glyphEntry{bitmap: bitmap, ok: ok})
+	return bitmap, ok
+}
+
+type glyphEntry struct {
+	bitmap *GlyphBitmap
+	ok     bool
+}
+
+// rasterizeFontGlyph copies the glyph mask from basicfont.Face7x13
+func rasterizeFontGlyph(r rune, bitmap *GlyphBitmap) bool {
+	face := basicfont.Face7x13
+	dr, mask, maskp, _, ok := face.Glyph(fixed.P(0, face.Ascent), r)
+	if !ok {
+		return false
+	}
+
+	for y := 0; y < dr.Dy() && y < FontCellHeight; y++ {
+		for x := 0; x < dr.Dx() && x < FontCellWidth; x++ {
+			_, _, _, a := mask.At(maskp.X+x, maskp.Y+y).RGBA()
+			bitmap[(dr.Min.Y+y)*FontCellWidth+dr.Min.X+x] = uint8(a >> 8)
+		}
+	}
+	return true
+}
+
+// rasterizeBlockElement draws full, half and shade blocks
+func rasterizeBlockElement(r rune, bitmap *GlyphBitmap) bool {
+	var fill func(x, y int) bool
+	switch r {
+	case '█':
+		fill = func(x, y int) bool { return true }
+	case '▀':
+		fill = func(x, y int) bool { return y < (FontCellHeight+1)/2 }
+	case '▄':
+		fill = func(x, y int) bool { return y >= (FontCellHeight+1)/2 }
+	case '░':
+		// 25% coverage: one pixel in each 2x2 block
+		fill = func(x, y int) bool { return x%2 == 0 && y%2 == 0 }
+	case '▒':
+		// 50% coverage: checkerboard
+		fill = func(x, y int) bool { return (x+y)%2 == 0 }
+	case '▓':
+		// 75% coverage: all but one pixel in each 2x2 block
+		fill = func(x, y int) bool { return !(x%2 == 1 && y%2 == 1) }
+	default:
+		return false
+	}
+
+	for y := 0; y < FontCellHeight; y++ {
+		for x := 0; x < FontCellWidth; x++ {
+			if fill(x, y) {
+				bitmap[y*FontCellWidth+x] = 255
+			}
+		}
+	}
+	return true
+}
+
+// rasterizeBraille draws the raised dots of a Braille pattern as 2x2 squares
+func rasterizeBraille(r rune, bitmap *GlyphBitmap) bool {
+	if r < brailleBase || r > brailleBase+0xff {
+		return false
+	}
+
+	// Dot centres: two columns, four rows spread over the cell
+	columns := [2]int{1, 4}
+	rows := [4]int{1, 4, 7, 10}
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 2; col++ {
+			if (r-brailleBase)&brailleDots[row][col] == 0 {
+				continue
+			}
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					bitmap[(rows[row]+dy)*FontCellWidth+columns[col]+dx] = 255
+				}
+			}
+		}
+	}
+	return true
+}
@@ -1,10 +1,95 @@
 package converter
 
 import (
+	"fmt"
 	"image"
-	"image/color"
+	"math"
 )
 
+// Grayscale models: how a color is reduced to the brightness that picks its glyph
+const (
+	GrayRec601    = "rec601"    // 0.299 R + 0.587 G + 0.114 B on gamma-encoded values (default)
+	GrayRec709    = "rec709"    // 0.2126 R + 0.7152 G + 0.0722 B on gamma-encoded values
+	GrayAverage   = "average"   // Plain mean of the three channels
+	GrayMax       = "max"       // Brightest channel (HSV value)
+	GrayLightness = "lightness" // Mean of the brightest and darkest channel (HSL lightness)
+	GrayRed       = "red"       // Red channel only
+	GrayGreen     = "green"     // Green channel only
+	GrayBlue      = "blue"      // Blue channel only
+	GrayLinear    = "linear"    // Rec.709 luminance computed in linear light, then sRGB-encoded
+)
+
+// grayModels maps each model to its brightness (0-255, unrounded) of 8-bit channel values
+var grayModels = map[string]func(r, g, b float64) float64{
+	GrayRec601: func(r, g, b float64) float64 {
+		return 0.299*r + 0.587*g + 0.114*b
+	},
+	GrayRec709: func(r, g, b float64) float64 {
+		return 0.2126*r + 0.7152*g + 0.0722*b
+	},
+	GrayAverage: func(r, g, b float64) float64 {
+		return (r + g + b) / 3
+	},
+	GrayMax: func(r, g, b float64) float64 {
+		return math.Max(r, math.Max(g, b))
+	},
+	GrayLightness: func(r, g, b float64) float64 {
+		return (math.Max(r, math.Max(g, b)) + math.Min(r, math.Min(g, b))) / 2
+	},
+	GrayRed: func(r, g, b float64) float64 {
+		return r
+	},
+	GrayGreen: func(r, g, b float64) float64 {
+		return g
+	},
+	GrayBlue: func(r, g, b float64) float64 {
+		return b
+	},
+	GrayLinear: func(r, g, b float64) float64 {
+		y := 0.2126*srgbToLinear[int(r)] + 0.7152*srgbToLinear[int(g)] + 0.0722*srgbToLinear[int(b)]
+		if y <= 0.0031308 {
+			return 255 * 12.92 * y
+		}
+		return 255 * (1.055*math.Pow(y, 1/2.4) - 0.055)
+	},
+}
+
+// srgbToLinear decodes 8-bit sRGB values to linear light (0-1)
+var srgbToLinear = func() (table [256]float64) {
+	for i := range table {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			table[i] = v / 12.92
+		} else {
+			table[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return table
+}()
+
+// ValidateGrayModel checks that model is a known grayscale model ("" means GrayRec601)
+func ValidateGrayModel(model string) error {
+	if _, ok := grayModels[model]; ok || model == "" {
+		return nil
+	}
+	return fmt.Errorf("unknown grayscale model %q (valid: %s, %s, %s, %s, %s, %s, %s, %s, %s)", model,
+		GrayRec601, GrayRec709, GrayAverage, GrayMax, GrayLightness, GrayRed, GrayGreen, GrayBlue, GrayLinear)
+}
+
+// GrayFunc returns the brightness function of a grayscale model. Like RGBToGrayScale it
+// takes 16-bit channels as returned by color.Color.RGBA. GrayRec601 (and "") is
+// RGBToGrayScale itself; the other models round to the nearest level, so gray pixels
+// keep their value.
+func GrayFunc(model string) func(r, g, b uint32) uint8 {
+	gray, ok := grayModels[model]
+	if !ok || model == GrayRec601 {
+		return RGBToGrayScale
+	}
+	return func(r, g, b uint32) uint8 {
+		return clampChannel(gray(float64(r>>8), float64(g>>8), float64(b>>8)))
+	}
+}
+
 func RGBToGrayScale(r, g, b uint32) uint8 {
 	r = r >> 8
 	g = g >> 8
@@ -12,15 +97,32 @@ func RGBToGrayScale(r, g, b uint32) uint8 {
 	return uint8((0.299 * float64(r)) + (0.587 * float64(g)) + (0.114 * float64(b)))
 }
 
+// ConvertToGrayscale converts img to an *image.Gray using RGBToGrayScale.
+// Rows are converted in parallel bands.
 func ConvertToGrayscale(img image.Image) image.Image {
+	return convertToGrayscale(img, RGBToGrayScale)
+}
+
+// ConvertToGrayscaleModel converts img to an *image.Gray using the brightness function of
+// a grayscale model (see GrayFunc; "" means GrayRec601, matching ConvertToGrayscale)
+func ConvertToGrayscaleModel(img image.Image, model string) image.Image {
+	return convertToGrayscale(img, GrayFunc(model))
+}
+
+// convertToGrayscale converts img to an *image.Gray using the brightness function gray
+func convertToGrayscale(img image.Image, gray func(r, g, b uint32) uint8) *image.Gray {
 	bounds := img.Bounds()
 	grayImg := image.NewGray(bounds)
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
-			brightness := RGBToGrayScale(r, g, b)
-			grayImg.Set(x, y, color.Gray{Y: brightness})
+	pixel := newPixelReader(img)
+	parallelRows(bounds.Min.Y, bounds.Max.Y, func(_, y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			offset := grayImg.PixOffset(bounds.Min.X, y)
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r, g, b, _ := pixel(x, y)
+				grayImg.Pix[offset] = gray(r, g, b)
+				offset++
+			}
 		}
-	}
+	})
 	return grayImg
 }
@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestConvertToGrayscaleModel(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{R: 90, G: 90, B: 90, A: 255})
+
+	tests := []struct {
+		model string
+		want  uint8
+	}{
+		{model: "", want: 124},
+		{model: GrayRec601, want: 124},
+		{model: GrayRec709, want: 118},
+		{model: GrayAverage, want: 117},
+		{model: GrayMax, want: 200},
+		{model: GrayLightness, want: 125},
+		{model: GrayRed, want: 200},
+		{model: GrayGreen, want: 100},
+		{model: GrayBlue, want: 50},
+		{model: GrayLinear, want: 128},
+	}
+	for _, tt := range tests {
+		gray, ok := ConvertToGrayscaleModel(img, tt.model).(*image.Gray)
+		if !ok {
+			t.Fatalf("%q: result is not an *image.Gray", tt.model)
+		}
+		if got := gray.GrayAt(0, 0).Y; got != tt.want {
+			t.Errorf("%q: color brightness = %d, want %d", tt.model, got, tt.want)
+		}
+		if got := gray.GrayAt(1, 0).Y; got != 90 {
+			t.Errorf("%q: gray brightness = %d, want 90", tt.model, got)
+		}
+	}
+}
+
+func TestValidateGrayModel(t *testing.T) {
+	for _, model := range []string{"", GrayRec601, GrayRec709, GrayAverage, GrayMax, GrayLightness, GrayRed, GrayGreen, GrayBlue, GrayLinear} {
+		if err := ValidateGrayModel(model); err != nil {
+			t.Errorf("ValidateGrayModel(%q) = %v", model, err)
+		}
+	}
+	for _, model := range []string{"luma", "REC601", " "} {
+		if err := ValidateGrayModel(model); err == nil {
+			t.Errorf("ValidateGrayModel(%q) accepted an unknown model", model)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"image"
+)
+
+// upperHalfBlock is drawn in the foreground color; the lower half of the cell shows the background color
+const upperHalfBlock = "▀"
+
+// ResizeImageForHalfBlock resizes an image so each output character covers a 1x2 pixel block.
+// Characters are roughly twice as tall as wide, so two stacked pixels per cell keep pixels
+// square and no extra aspect correction is needed.
+func ResizeImageForHalfBlock(img image.Image, targetWidth int) image.Image {
+	width, height := halfBlockDimensions(img.Bounds(), targetWidth)
+	return Resample(img, width, height, ResampleLanczos)
+}
+
+// halfBlockDimensions returns the pixel size ResizeImageForHalfBlock scales to, at most
+// MaxBoxSize cells tall
+func halfBlockDimensions(bounds image.Rectangle, targetWidth int) (int, int) {
+	originalWidth := bounds.Max.X - bounds.Min.X
+	originalHeight := bounds.Max.Y - bounds.Min.Y
+
+	scale := float64(targetWidth) / float64(originalWidth)
+	pixelHeight := int(float64(originalHeight) * scale)
+	pixelHeight = min(max(pixelHeight, 1), 2*MaxBoxSize)
+
+	return targetWidth, pixelHeight
+}
+
+// ConvertToHalfBlockStructured renders two vertical pixels per character cell.
+// The top pixel becomes the foreground color of "▀" and the bottom pixel its background,
+// doubling vertical resolution compared to one glyph per pixel. When the image has an odd
+// height, the last row uses "▀" with no background.
+func ConvertToHalfBlockStructured(img image.Image) ColoredASCII {
+	bounds := img.Bounds()
+	lines := make([][]ColoredChar, 0, (bounds.Dy()+1)/2)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		line := make([]ColoredChar, 0, bounds.Dx())
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			top := pixelRGB(img, x, y)
+			char := ColoredChar{Char: upperHalfBlock, R: top.R, G: top.G, B: top.B}
+			if y+1 < bounds.Max.Y {
+				bottom := pixelRGB(img, x, y+1)
+				char.Background = &bottom
+			}
+			line = append(line, char)
+		}
+		lines = append(lines, line)
+	}
+
+	return ColoredASCII{Lines: lines}
+}
+
+// ConvertToHalfBlockWithColor renders an image with half blocks and 24-bit ANSI
+// foreground/background escapes, suitable for printing to a truecolor terminal
+func ConvertToHalfBlockWithColor(img image.Image) string {
+	return ColoredASCIIToANSI(ConvertToHalfBlockStructured(img))
+}
+
+// pixelRGB returns the 8-bit RGB color of the pixel at (x, y)
+func pixelRGB(img image.Image, x, y int) RGB {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return RGB{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8)}
+}
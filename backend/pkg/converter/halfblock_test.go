@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestConvertToHalfBlockStructured(t *testing.T) {
+	// 2x3 pixels: two full cells, then a last row with no bottom pixel
+	img := image.NewRGBA(image.Rect(0, 0, 2, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 2; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(10 * x), G: uint8(10 * y), B: 7, A: 255})
+		}
+	}
+
+	got := ConvertToHalfBlockStructured(img)
+	if len(got.Lines) != 2 || len(got.Lines[0]) != 2 || len(got.Lines[1]) != 2 {
+		t.Fatalf("got %d lines, want 2 lines of 2 cells", len(got.Lines))
+	}
+	tests := []struct {
+		x, y       int
+		fg         RGB
+		background *RGB
+	}{
+		{x: 0, y: 0, fg: RGB{R: 0, G: 0, B: 7}, background: &RGB{R: 0, G: 10, B: 7}},
+		{x: 1, y: 0, fg: RGB{R: 10, G: 0, B: 7}, background: &RGB{R: 10, G: 10, B: 7}},
+		{x: 1, y: 1, fg: RGB{R: 10, G: 20, B: 7}},
+	}
+	for _, tt := range tests {
+		cell := got.Lines[tt.y][tt.x]
+		if cell.Char != upperHalfBlock {
+			t.Errorf("cell (%d, %d): char %q, want %q", tt.x, tt.y, cell.Char, upperHalfBlock)
+		}
+		if fg := (RGB{R: cell.R, G: cell.G, B: cell.B}); fg != tt.fg {
+			t.Errorf("cell (%d, %d): foreground %v, want %v", tt.x, tt.y, fg, tt.fg)
+		}
+		switch {
+		case tt.background == nil && cell.Background != nil:
+			t.Errorf("cell (%d, %d): background %v, want none", tt.x, tt.y, *cell.Background)
+		case tt.background != nil && (cell.Background == nil || *cell.Background != *tt.background):
+			t.Errorf("cell (%d, %d): background %v, want %v", tt.x, tt.y, cell.Background, *tt.background)
+		}
+	}
+}
+
+func TestResizeImageForHalfBlock(t *testing.T) {
+	tests := []struct {
+		width, height, targetWidth int
+		wantWidth, wantHeight      int
+	}{
+		{width: 100, height: 100, targetWidth: 50, wantWidth: 50, wantHeight: 50},
+		{width: 100, height: 50, targetWidth: 10, wantWidth: 10, wantHeight: 5},
+		{width: 1000, height: 1, targetWidth: 10, wantWidth: 10, wantHeight: 1},
+	}
+	for _, tt := range tests {
+		size := ResizeImageForHalfBlock(image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)), tt.targetWidth).Bounds().Size()
+		if size.X != tt.wantWidth || size.Y != tt.wantHeight {
+			t.Errorf("%dx%d at width %d: got %v, want %dx%d", tt.width, tt.height, tt.targetWidth, size, tt.wantWidth, tt.wantHeight)
+		}
+	}
+}
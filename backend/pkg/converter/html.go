@@ -0,0 +1,155 @@
+package converter
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HTMLOptions controls how ASCII art is rendered as HTML. All styling is inline, so the
+// output survives being pasted into wikis and emails that strip stylesheets.
+type HTMLOptions struct {
+	FontFamily string  // CSS font-family list (default: monospace)
+	FontSize   int     // Font size in pixels
+	LineHeight float64 // Line height as a multiple of the font size
+	Background RGB     // Page background
+	Foreground RGB     // Color of plain text output
+	Fragment   bool    // Write only the <pre> element, for embedding, instead of a whole document
+}
+
+// DefaultHTMLOptions returns white 12px monospace text on black, as a whole document
+func DefaultHTMLOptions() HTMLOptions {
+	return HTMLOptions{
+		FontFamily: "monospace",
+		FontSize:   12,
+		LineHeight: 1,
+		Foreground: RGB{R: 255, G: 255, B: 255},
+	}
+}
+
+// Validate checks the font size and line height, and that the font family cannot break out
+// of its CSS declaration
+func (o HTMLOptions) Validate() error {
+	if o.FontSize <= 0 {
+		return fmt.Errorf("font size must be positive")
+	}
+	if !(o.LineHeight > 0) || math.IsInf(o.LineHeight, 1) {
+		return fmt.Errorf("line height must be positive")
+	}
+	if strings.ContainsAny(o.FontFamily, ";{}<>\"\\") {
+		return fmt.Errorf("invalid font family %q", o.FontFamily)
+	}
+	return nil
+}
+
+// textRun is a run of consecutive characters on one line sharing the same colors.
+// Unstyled runs hold only blank cells and inherit the colors of the enclosing element.
+type textRun struct {
+	text       strings.Builder
+	styled     bool
+	color      RGB
+	background *RGB
+}
+
+// ConvertToHTML renders ASCII art as a <pre> element, wrapped in a minimal HTML document
+// unless opts.Fragment is set. Colored output is written as <span> runs of consecutive
+// characters sharing a color; blank cells without a background join the run before them,
+// and transparent cells are left unstyled.
+func ConvertToHTML(asciiArt string, coloredASCII *ColoredASCII, opts HTMLOptions) string {
+	fontFamily := opts.FontFamily
+	if fontFamily == "" {
+		fontFamily = "monospace"
+	}
+	style := fmt.Sprintf("margin:0;padding:0.5em;background:%s;color:%s;font-family:%s;font-size:%dpx;line-height:%s",
+		cssColor(opts.Background), cssColor(opts.Foreground), fontFamily, opts.FontSize,
+		strconv.FormatFloat(opts.LineHeight, 'f', -1, 64))
+
+	var out strings.Builder
+	if !opts.Fragment {
+		out.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>ASCII art</title>\n</head>\n")
+		out.WriteString(fmt.Sprintf("<body style=\"margin:0;background:%s\">\n", cssColor(opts.Background)))
+	}
+	out.WriteString(fmt.Sprintf(`<pre style="%s">`, html.EscapeString(style)))
+
+	if coloredASCII != nil {
+		for i, line := range coloredASCII.Lines {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			for _, run := range textRuns(line, true) {
+				writeHTMLRun(&out, run)
+			}
+		}
+	} else {
+		out.WriteString(html.EscapeString(strings.TrimRight(asciiArt, "\n")))
+	}
+
+	out.WriteString("</pre>\n")
+	if !opts.Fragment {
+		out.WriteString("</body>\n</html>\n")
+	}
+	return out.String()
+}
+
+// textRuns merges a line of cells into runs of the same colors. Without backgrounds, cell
+// backgrounds are ignored (for exporters that paint them separately).
+func textRuns(line []ColoredChar, backgrounds bool) []*textRun {
+	var runs []*textRun
+	for _, char := range line {
+		var last *textRun
+		if len(runs) > 0 {
+			last = runs[len(runs)-1]
+		}
+		background := char.Background
+		if !backgrounds {
+			background = nil
+		}
+
+		// A blank cell shows no foreground color, so it can join any run without a background
+		blank := char.Transparent || (background == nil && strings.TrimSpace(char.Char) == "")
+		switch {
+		case blank && last != nil && last.background == nil:
+			last.text.WriteString(char.Char)
+			continue
+		case blank:
+			runs = append(runs, &textRun{})
+		case last == nil || !last.styled || last.color != (RGB{R: char.R, G: char.G, B: char.B}) || !sameBackground(last.background, background):
+			runs = append(runs, &textRun{styled: true, color: RGB{R: char.R, G: char.G, B: char.B}, background: background})
+		}
+		runs[len(runs)-1].text.WriteString(char.Char)
+	}
+	return runs
+}
+
+// writeHTMLRun writes a run as escaped text, wrapped in a <span> when it is styled
+func writeHTMLRun(out *strings.Builder, run *textRun) {
+	text := html.EscapeString(run.text.String())
+	if !run.styled {
+		out.WriteString(text)
+		return
+	}
+	out.WriteString(`<span style="color:`)
+	out.WriteString(cssColor(run.color))
+	if run.background != nil {
+		out.WriteString(";background:")
+		out.WriteString(cssColor(*run.background))
+	}
+	out.WriteString(`">`)
+	out.WriteString(text)
+	out.WriteString("</span>")
+}
+
+// sameBackground reports whether two optional background colors are equal
+func sameBackground(a, b *RGB) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// cssColor formats c as #rrggbb
+func cssColor(c RGB) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
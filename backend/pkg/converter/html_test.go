@@ -0,0 +1,71 @@
+package converter
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHTMLOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*HTMLOptions)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(o *HTMLOptions) {}},
+		{name: "font list", modify: func(o *HTMLOptions) { o.FontFamily = "'DejaVu Sans Mono', Menlo, monospace" }},
+		{name: "zero font size", modify: func(o *HTMLOptions) { o.FontSize = 0 }, wantErr: true},
+		{name: "zero line height", modify: func(o *HTMLOptions) { o.LineHeight = 0 }, wantErr: true},
+		{name: "NaN line height", modify: func(o *HTMLOptions) { o.LineHeight = math.NaN() }, wantErr: true},
+		{name: "infinite line height", modify: func(o *HTMLOptions) { o.LineHeight = math.Inf(1) }, wantErr: true},
+		{name: "declaration break", modify: func(o *HTMLOptions) { o.FontFamily = "monospace;background:url(x)" }, wantErr: true},
+		{name: "attribute break", modify: func(o *HTMLOptions) { o.FontFamily = `mono"><script>` }, wantErr: true},
+		{name: "escape", modify: func(o *HTMLOptions) { o.FontFamily = `mono\3b` }, wantErr: true},
+	}
+	for _, tt := range tests {
+		opts := DefaultHTMLOptions()
+		tt.modify(&opts)
+		if err := opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConvertToHTML(t *testing.T) {
+	red, blue := RGB{R: 255}, RGB{B: 255}
+	fragment := DefaultHTMLOptions()
+	fragment.Fragment = true
+
+	tests := []struct {
+		name    string
+		text    string
+		colored *ColoredASCII
+		want    string // Content of the <pre> element
+	}{
+		{name: "plain text is escaped", text: "<a&b>\n\"'\n", want: "&lt;a&amp;b&gt;\n&#34;&#39;"},
+		{
+			name: "runs merge colors and blanks",
+			colored: &ColoredASCII{Lines: [][]ColoredChar{
+				{{Char: "<", R: 255}, {Char: " "}, {Char: "&", R: 255}, {Char: "b", B: 255}},
+				{{Char: " ", Background: &blue}, {Char: " ", Transparent: true}, {Char: "y", R: 255, Background: &red}},
+			}},
+			want: `<span style="color:#ff0000">&lt; &amp;</span><span style="color:#0000ff">b</span>` + "\n" +
+				`<span style="color:#000000;background:#0000ff"> </span> <span style="color:#ff0000;background:#ff0000">y</span>`,
+		},
+	}
+	for _, tt := range tests {
+		got := ConvertToHTML(tt.text, tt.colored, fragment)
+		start := strings.Index(got, ">") + 1
+		if !strings.HasPrefix(got, "<pre style=") || !strings.HasSuffix(got, "</pre>\n") {
+			t.Fatalf("%s: not a <pre> fragment: %q", tt.name, got)
+		}
+		if content := strings.TrimSuffix(got[start:], "</pre>\n"); content != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, content, tt.want)
+		}
+	}
+
+	document := ConvertToHTML("a", nil, DefaultHTMLOptions())
+	if !strings.HasPrefix(document, "<!DOCTYPE html>") || !strings.HasSuffix(document, "</html>\n") {
+		t.Errorf("document output is not a whole HTML document: %q", document)
+	}
+}
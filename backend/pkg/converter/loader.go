@@ -1,67 +1,215 @@
 package converter
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"image"
+	"image/draw"
+	"image/gif"
 	"io"
+	"os"
+	"strings"
 
 	// Import image format decoders
 	// The _ means "import for side effects only"
 	// These packages register their decoders with the image package
 	_ "image/jpeg"
 	_ "image/png"
-	"os"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
 )
 
+// ErrUnsupportedFormat is returned when an image is not in one of the SupportedFormats
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// ImageFormat describes an image format the loader can decode
+type ImageFormat struct {
+	Name       string   `json:"name"`       // Format name as returned by the loader
+	Extensions []string `json:"extensions"` // Usual file extensions
+	MIMEType   string   `json:"mimeType"`
+	Animated   bool     `json:"animated"` // Files may hold several frames (see LoadImageFrame)
+}
+
+// supportedFormats lists the registered decoders; WebP animations are not decoded
+var supportedFormats = []ImageFormat{
+	{Name: "jpeg", Extensions: []string{".jpg", ".jpeg"}, MIMEType: "image/jpeg"},
+	{Name: "png", Extensions: []string{".png"}, MIMEType: "image/png"},
+	{Name: "gif", Extensions: []string{".gif"}, MIMEType: "image/gif", Animated: true},
+	{Name: "bmp", Extensions: []string{".bmp"}, MIMEType: "image/bmp"},
+	{Name: "tiff", Extensions: []string{".tif", ".tiff"}, MIMEType: "image/tiff"},
+	{Name: "webp", Extensions: []string{".webp"}, MIMEType: "image/webp"},
+}
+
+// SupportedFormats returns the image formats the loader can decode
+func SupportedFormats() []ImageFormat {
+	formats := make([]ImageFormat, len(supportedFormats))
+	copy(formats, supportedFormats)
+	return formats
+}
+
+// supportedFormatNames returns the format names for error messages, e.g. "jpeg, png, gif"
+func supportedFormatNames() string {
+	names := make([]string, len(supportedFormats))
+	for i, format := range supportedFormats {
+		names[i] = format.Name
+	}
+	return strings.Join(names, ", ")
+}
+
 // LoadImage reads an image file from the given path and decodes it.
-// It returns the decoded image and any error encountered.
-// Supported formats: JPEG, PNG (can add GIF, WebP, etc. by importing their packages)
-func LoadImage(filePath string) (image.Image, error) {
+// It returns the decoded image, its format name (see SupportedFormats) and any error encountered.
+// Animated GIFs return their first frame; use LoadImageFrame to pick another.
+// As with LoadImageFromReader, JPEG and TIFF photos are returned upright.
+func LoadImage(filePath string) (image.Image, string, error) {
+	return LoadImageFrame(filePath, 0)
+}
+
+// LoadImageFrame is LoadImage for frame number frame (counted from 0) of an animated GIF.
+// Other formats only have frame 0.
+func LoadImageFrame(filePath string, frame int) (image.Image, string, error) {
 	// Step 1: Open the file
 	// os.Open returns a *os.File which implements io.Reader
 	file, err := os.Open(filePath)
 	if err != nil {
 		// Wrap the error with context about what we were trying to do
-		return nil, fmt.Errorf("failed to open image file: %w", err)
+		return nil, "", fmt.Errorf("failed to open image file: %w", err)
 	}
 	// defer ensures the file is closed when the function returns
 	// This happens even if we return early due to an error
 	defer file.Close()
 
-	// Step 2: Decode the image
-	// image.Decode automatically detects the format by reading the file header
-	// It then uses the appropriate registered decoder (JPEG, PNG, etc.)
-	img, format, err := image.Decode(file)
+	// Step 2: Decode the image (see LoadImageFrameFromReader)
+	return LoadImageFrameFromReader(file, frame)
+}
+
+// LoadImageFromReader reads an image from an io.Reader and decodes it.
+// It returns the decoded image, its format name and any error encountered; data in a
+// format outside SupportedFormats returns ErrUnsupportedFormat.
+// JPEG and TIFF photos are turned upright according to their EXIF orientation (see Orient),
+// so the returned image's bounds are the dimensions the photo is displayed at.
+// This function is useful for API endpoints that receive image data via HTTP requests.
+func LoadImageFromReader(reader io.Reader) (image.Image, string, error) {
+	return LoadImageFrameFromReader(reader, 0)
+}
+
+// LoadImageFrameFromReader is LoadImageFromReader for frame number frame (counted from 0)
+// of an animated GIF. Each GIF frame is composited over the ones before it, so a frame
+// that only updates part of the picture is returned whole.
+func LoadImageFrameFromReader(reader io.Reader, frame int) (image.Image, string, error) {
+	if frame < 0 {
+		return nil, "", fmt.Errorf("invalid frame %d (frames are counted from 0)", frame)
+	}
+
+	// Read the whole file: the EXIF orientation has to be looked up in the raw bytes
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	// Detect the format from the file header before decoding any pixels
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, "", fmt.Errorf("%w (supported: %s)", ErrUnsupportedFormat, supportedFormatNames())
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
+		return nil, format, fmt.Errorf("failed to decode %s image: %w", format, err)
 	}
 
-	// Step 3: Optional - log what we decoded (useful for debugging)
-	// In production, you might want to use a proper logger
-	fmt.Printf("Successfully loaded %s image\n", format)
+	// Only GIFs carry several frames
+	if format == "gif" {
+		img, err := decodeGIFFrame(data, frame)
+		if err != nil {
+			return nil, format, err
+		}
+		return img, format, nil
+	}
+	if frame > 0 {
+		return nil, format, fmt.Errorf("frame %d requested, but %s images have a single frame", frame, format)
+	}
+
+	// image.Decode uses the registered decoder for the detected format
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, format, fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
 
-	// Step 4: Return the decoded image
-	// img is of type image.Image (an interface)
-	// The actual concrete type depends on the format (e.g., *image.YCbCr for JPEG)
-	return img, nil
+	// Cameras store photos in sensor order and record how to rotate them in EXIF;
+	// a TIFF file keeps the tag in its own first IFD
+	switch format {
+	case "jpeg":
+		img = Orient(img, jpegOrientation(data))
+	case "tiff":
+		img = Orient(img, exifOrientation(data))
+	}
+
+	// Return the decoded image
+	return img, format, nil
 }
 
-// LoadImageFromReader reads an image from an io.Reader and decodes it.
-// It returns the decoded image and any error encountered.
-// Supported formats: JPEG, PNG (can add GIF, WebP, etc. by importing their packages)
-// This function is useful for API endpoints that receive image data via HTTP requests.
-func LoadImageFromReader(reader io.Reader) (image.Image, error) {
-	// Decode the image from the reader
-	// image.Decode automatically detects the format by reading the file header
-	// It then uses the appropriate registered decoder (JPEG, PNG, etc.)
-	img, format, err := image.Decode(reader)
+// decodeGIFFrame decodes frame number index of a GIF. The first frame is decoded on its
+// own; later frames need every frame before them.
+func decodeGIFFrame(data []byte, index int) (image.Image, error) {
+	config, err := gif.DecodeConfig(bytes.NewReader(data))
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
+		return nil, fmt.Errorf("failed to decode gif image: %w", err)
 	}
+	screen := image.Rect(0, 0, config.Width, config.Height)
 
-	// Optional - log what we decoded (useful for debugging)
-	fmt.Printf("Successfully loaded %s image\n", format)
+	if index == 0 {
+		first, err := gif.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gif image: %w", err)
+		}
+		return composeGIFFrame(screen, []*image.Paletted{first.(*image.Paletted)}, nil, 0), nil
+	}
 
-	// Return the decoded image
-	return img, nil
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gif image: %w", err)
+	}
+	if index >= len(anim.Image) {
+		return nil, fmt.Errorf("frame %d out of range (the gif has %d frames)", index, len(anim.Image))
+	}
+	return composeGIFFrame(screen, anim.Image, anim.Disposal, index), nil
+}
+
+// composeGIFFrame draws frames 0 through index onto a transparent screen, applying each
+// frame's disposal method before the next is drawn, and returns the picture shown at index.
+// A frame that covers the whole screen on its own is returned as is.
+func composeGIFFrame(screen image.Rectangle, frames []*image.Paletted, disposal []byte, index int) image.Image {
+	if index == 0 && frames[0].Bounds() == screen {
+		return frames[0]
+	}
+
+	canvas := image.NewRGBA(screen)
+	for i := 0; i <= index; i++ {
+		frame := frames[i]
+		var dispose byte
+		if i < len(disposal) {
+			dispose = disposal[i]
+		}
+
+		// DisposalPrevious restores the screen as it was before this frame
+		var previous *image.RGBA
+		if dispose == gif.DisposalPrevious && i < index {
+			previous = image.NewRGBA(screen)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if i == index {
+			break
+		}
+
+		switch dispose {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return canvas
 }
@@ -0,0 +1,143 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+func TestLoadImageFromReaderFormats(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 12, 7))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+
+	tests := []struct {
+		format string
+		encode func(w io.Writer) error
+	}{
+		{format: "png", encode: func(w io.Writer) error { return png.Encode(w, img) }},
+		{format: "jpeg", encode: func(w io.Writer) error { return jpeg.Encode(w, img, nil) }},
+		{format: "gif", encode: func(w io.Writer) error { return gif.Encode(w, paletted, nil) }},
+		{format: "bmp", encode: func(w io.Writer) error { return bmp.Encode(w, img) }},
+		{format: "tiff", encode: func(w io.Writer) error { return tiff.Encode(w, img, nil) }},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := tt.encode(&buf); err != nil {
+			t.Fatalf("%s: encoding: %v", tt.format, err)
+		}
+		decoded, format, err := LoadImageFromReader(&buf)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if format != tt.format {
+			t.Errorf("%s: format %q", tt.format, format)
+		}
+		if size := decoded.Bounds().Size(); size != (image.Point{X: 12, Y: 7}) {
+			t.Errorf("%s: size %v, want 12x7", tt.format, size)
+		}
+	}
+}
+
+func TestLoadImageFromReaderErrors(t *testing.T) {
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewGray(image.Rect(0, 0, 2, 2)))
+
+	tests := []struct {
+		name        string
+		data        []byte
+		frame       int
+		unsupported bool
+	}{
+		{name: "unknown format", data: []byte("definitely not an image"), unsupported: true},
+		{name: "empty", data: nil, unsupported: true},
+		{name: "truncated png", data: pngData.Bytes()[:30]},
+		{name: "negative frame", data: pngData.Bytes(), frame: -1},
+		{name: "second frame of a png", data: pngData.Bytes(), frame: 1},
+	}
+	for _, tt := range tests {
+		_, _, err := LoadImageFrameFromReader(bytes.NewReader(tt.data), tt.frame)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+			continue
+		}
+		if errors.Is(err, ErrUnsupportedFormat) != tt.unsupported {
+			t.Errorf("%s: error %v, want ErrUnsupportedFormat %t", tt.name, err, tt.unsupported)
+		}
+	}
+}
+
+func TestLoadImageFrameGIF(t *testing.T) {
+	// A red 4x4 screen, a green 2x2 patch in the top left that is cleared afterwards, then a
+	// blue pixel in the bottom right
+	colors := color.Palette{color.Transparent, color.RGBA{R: 255, A: 255}, color.RGBA{G: 255, A: 255}, color.RGBA{B: 255, A: 255}}
+	frame := func(rect image.Rectangle, index uint8) *image.Paletted {
+		img := image.NewPaletted(rect, colors)
+		for i := range img.Pix {
+			img.Pix[i] = index
+		}
+		return img
+	}
+	anim := &gif.GIF{
+		Image:    []*image.Paletted{frame(image.Rect(0, 0, 4, 4), 1), frame(image.Rect(0, 0, 2, 2), 2), frame(image.Rect(3, 3, 4, 4), 3)},
+		Delay:    []int{10, 10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalNone},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	red, green, blue := color.RGBA{R: 255, A: 255}, color.RGBA{G: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	tests := []struct {
+		frame   int
+		corner  color.RGBA // Pixel (0, 0)
+		far     color.RGBA // Pixel (3, 3)
+		wantErr bool
+	}{
+		{frame: 0, corner: red, far: red},
+		{frame: 1, corner: green, far: red},
+		{frame: 2, corner: color.RGBA{}, far: blue},
+		{frame: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		img, format, err := LoadImageFrameFromReader(bytes.NewReader(buf.Bytes()), tt.frame)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("frame %d: error = %v, want error %t", tt.frame, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if format != "gif" || img.Bounds() != image.Rect(0, 0, 4, 4) {
+			t.Errorf("frame %d: got %s image %v, want a 4x4 gif", tt.frame, format, img.Bounds())
+		}
+		if got := color.RGBAModel.Convert(img.At(0, 0)); got != tt.corner {
+			t.Errorf("frame %d: pixel (0, 0) = %v, want %v", tt.frame, got, tt.corner)
+		}
+		if got := color.RGBAModel.Convert(img.At(3, 3)); got != tt.far {
+			t.Errorf("frame %d: pixel (3, 3) = %v, want %v", tt.frame, got, tt.far)
+		}
+	}
+}
+
+func TestSupportedFormats(t *testing.T) {
+	formats := SupportedFormats()
+	formats[0].Name = "changed"
+	if SupportedFormats()[0].Name == "changed" {
+		t.Error("SupportedFormats returned the package's own slice")
+	}
+}
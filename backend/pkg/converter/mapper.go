@@ -1,9 +1,12 @@
 package converter
 
 import (
+	"fmt"
 	"image"
 	"image/color"
+	"runtime"
 	"strings"
+	"sync/atomic"
 )
 
 // Palette types
@@ -14,59 +17,134 @@ const (
 	PaletteUnicode = "unicode"
 )
 
-// GetPalette returns the character palette string for the given palette type
-func GetPalette(paletteType string) string {
-	switch paletteType {
-	case PaletteDense:
-		return ".oO0@#"
-	case PaletteSparse:
-		return " .'`^\""
-	case PaletteUnicode:
-		// Unicode block characters: light shade, medium shade, dark shade, full block
-		// Using explicit runes to ensure proper UTF-8 encoding
-		return string([]rune{'\u2591', '\u2592', '\u2593', '\u2588'}) // ░▒▓█
-	case PaletteNormal:
-		fallthrough
+// Render modes
+const (
+	ModeASCII     = "ascii"     // One palette glyph per pixel, chosen by brightness
+	ModeBraille   = "braille"   // One Braille pattern per 2x4 pixel block
+	ModeHalfBlock = "halfblock" // Two vertical pixels per cell as foreground/background colors
+	ModeEdges     = "edges"     // Directional glyphs along strong edges, palette glyphs elsewhere
+	ModeShape     = "shape"     // Palette glyph whose bitmap best matches each cell's pixel pattern
+)
+
+// ValidateMode checks that mode is a known render mode
+func ValidateMode(mode string) error {
+	switch mode {
+	case ModeASCII, ModeBraille, ModeHalfBlock, ModeEdges, ModeShape:
+		return nil
+	default:
+		return fmt.Errorf("unknown mode %q (valid: %s, %s, %s, %s, %s)", mode, ModeASCII, ModeBraille, ModeHalfBlock, ModeEdges, ModeShape)
+	}
+}
+
+// CellSize returns how many pixels of the resized image each output character covers in mode
+func CellSize(mode string) (width, height int) {
+	switch mode {
+	case ModeBraille:
+		return 2, 4
+	case ModeHalfBlock:
+		return 1, 2
+	case ModeShape:
+		return FontCellWidth, FontCellHeight
 	default:
-		return " .:-=+*#%@"
+		return 1, 1
 	}
 }
 
+// ModeRequiresColor reports whether mode only makes sense with color output
+func ModeRequiresColor(mode string) bool {
+	return mode == ModeHalfBlock
+}
+
+// GetPalette returns the character palette string for the given palette type.
+// It looks the name up in DefaultPalettes and returns ErrUnknownPalette for unregistered names.
+func GetPalette(paletteType string) (string, error) {
+	return DefaultPalettes.Lookup(paletteType)
+}
+
+// BrightnessToChar returns the glyph of palette for a brightness. The palette's glyphs are
+// cached between calls with the same palette; mapping a whole image is faster still with a
+// 256-entry table built from it (see glyphTable).
 func BrightnessToChar(brightness uint8, palette string) string {
 	// palette should be the actual character palette string, not the type
 	if len(palette) == 0 {
-		palette = GetPalette(PaletteNormal)
+		palette, _ = GetPalette(PaletteNormal)
 	}
 
-	// Convert string to rune slice to handle multi-byte Unicode characters correctly
-	runes := []rune(palette)
-	if len(runes) == 0 {
+	ramp := lastGlyphRamp.Load()
+	if ramp == nil || ramp.palette != palette {
+		// Split by rune to handle multi-byte Unicode characters correctly
+		ramp = &glyphRamp{palette: palette}
+		for _, r := range palette {
+			ramp.glyphs = append(ramp.glyphs, string(r))
+		}
+		lastGlyphRamp.Store(ramp)
+	}
+	if len(ramp.glyphs) == 0 {
 		return " "
 	}
 
-	index := float64(brightness) / 255.0 * float64(len(runes)-1)
-	return string(runes[int(index)])
+	index := float64(brightness) / 255.0 * float64(len(ramp.glyphs)-1)
+	return ramp.glyphs[int(index)]
+}
+
+// glyphRamp is a palette split into its glyphs
+type glyphRamp struct {
+	palette string
+	glyphs  []string
 }
 
-func ConvertToASCII(img image.Image, palette string) string {
+// lastGlyphRamp holds the palette BrightnessToChar was last called with
+var lastGlyphRamp atomic.Pointer[glyphRamp]
+
+// ConvertToASCII maps a grayscale image to ASCII art using the named palette. Unknown names
+// return ErrUnknownPalette. See ConvertToASCIIWithRamp for inline ramps.
+func ConvertToASCII(img image.Image, palette string) (string, error) {
+	charPalette, err := GetPalette(palette)
+	if err != nil {
+		return "", err
+	}
+	return ConvertToASCIIWithRamp(img, charPalette), nil
+}
+
+// ConvertToASCIIWithRamp maps a grayscale image to ASCII art.
+// charPalette is the character ramp (see GetPalette and ResolvePalette), ordered dark to bright.
+// Rows are mapped in parallel bands through a brightness-to-glyph lookup table.
+func ConvertToASCIIWithRamp(img image.Image, charPalette string) string {
 	bounds := img.Bounds()
-	// Convert palette type to actual character palette
-	charPalette := GetPalette(palette)
+	glyphs := glyphTable(charPalette)
+
+	// Read *image.Gray pixels directly; other images must still hold color.Gray values
+	brightnessAt := func(x, y int) uint8 {
+		return img.At(x, y).(color.Gray).Y
+	}
+	if gray, ok := img.(*image.Gray); ok {
+		brightnessAt = func(x, y int) uint8 {
+			return gray.Pix[gray.PixOffset(x, y)]
+		}
+	}
 
 	// strings.Builder is much more efficient than string concatenation
 	// It preallocates memory and avoids creating new strings on each append
-	var builder strings.Builder
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			brightness := img.At(x, y).(color.Gray).Y
-			// WriteString appends to the builder without allocating new strings
-			builder.WriteString(BrightnessToChar(brightness, charPalette))
+	// Each band writes into its own builder; they are joined in row order
+	builders := make([]strings.Builder, runtime.GOMAXPROCS(0))
+	bands := parallelRows(bounds.Min.Y, bounds.Max.Y, func(band, y0, y1 int) {
+		builder := &builders[band]
+		for y := y0; y < y1; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				// WriteString appends to the builder without allocating new strings
+				builder.WriteString(glyphs[brightnessAt(x, y)])
+			}
+			// Add newline at the end of each row
+			builder.WriteString("\n")
 		}
-		// Add newline at the end of each row
-		builder.WriteString("\n")
-	}
+	})
 
-	// Convert the builder's contents to a final string
+	if bands == 1 {
+		return builders[0].String()
+	}
+	var builder strings.Builder
+	for band := 0; band < bands; band++ {
+		builder.WriteString(builders[band].String())
+	}
 	return builder.String()
 }
@@ -0,0 +1,227 @@
+package converter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ErrUnknownPalette is returned when a palette name is not registered
+var ErrUnknownPalette = errors.New("unknown palette")
+
+// PaletteInfo describes a registered palette
+type PaletteInfo struct {
+	Name       string `json:"name"`
+	Chars      string `json:"chars"`
+	GlyphCount int    `json:"glyphCount"`
+	MultiByte  bool   `json:"multiByte"`
+}
+
+// paletteFile is the on-disk format for palettes loaded from JSON or TOML.
+// A file may define a single palette (name + chars) or a list of palettes.
+type paletteFile struct {
+	Name     string        `json:"name" toml:"name"`
+	Chars    string        `json:"chars" toml:"chars"`
+	Palettes []paletteFile `json:"palettes" toml:"palettes"`
+}
+
+// PaletteRegistry holds named character palettes.
+// It is safe for concurrent use, so palettes can be registered at runtime
+// while the server is handling requests.
+type PaletteRegistry struct {
+	mu       sync.RWMutex
+	palettes map[string]string
+}
+
+// NewPaletteRegistry creates a registry pre-populated with the built-in palettes
+func NewPaletteRegistry() *PaletteRegistry {
+	r := &PaletteRegistry{palettes: make(map[string]string)}
+	r.palettes[PaletteNormal] = " .:-=+*#%@"
+	r.palettes[PaletteDense] = ".oO0@#"
+	r.palettes[PaletteSparse] = " .'`^\""
+	// Unicode block characters: light shade, medium shade, dark shade, full block
+	// Using explicit runes to ensure proper UTF-8 encoding
+	r.palettes[PaletteUnicode] = string([]rune{'░', '▒', '▓', '█'}) // ░▒▓█
+	return r
+}
+
+// DefaultPalettes is the registry used by GetPalette and the package-level helpers
+var DefaultPalettes = NewPaletteRegistry()
+
+// ValidatePaletteChars checks that a character ramp can be used for mapping
+func ValidatePaletteChars(chars string) error {
+	if chars == "" {
+		return fmt.Errorf("palette must contain at least one character")
+	}
+	if !utf8.ValidString(chars) {
+		return fmt.Errorf("palette is not valid UTF-8")
+	}
+	if strings.ContainsAny(chars, "\n\r\t") {
+		return fmt.Errorf("palette must not contain newlines or tabs")
+	}
+	return nil
+}
+
+// Register adds or replaces a palette under the given name
+func (r *PaletteRegistry) Register(name, chars string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("palette name must not be empty")
+	}
+	if err := ValidatePaletteChars(chars); err != nil {
+		return fmt.Errorf("invalid palette %q: %w", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.palettes[name] = chars
+	return nil
+}
+
+// Lookup returns the character ramp registered under name
+func (r *PaletteRegistry) Lookup(name string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chars, ok := r.palettes[name]
+	if !ok {
+		return "", fmt.Errorf("%w %q (available: %s)", ErrUnknownPalette, name, strings.Join(r.namesLocked(), ", "))
+	}
+	return chars, nil
+}
+
+// Info returns metadata about the palette registered under name
+func (r *PaletteRegistry) Info(name string) (PaletteInfo, error) {
+	chars, err := r.Lookup(name)
+	if err != nil {
+		return PaletteInfo{}, err
+	}
+	return newPaletteInfo(name, chars), nil
+}
+
+// List returns metadata for every registered palette, sorted by name
+func (r *PaletteRegistry) List() []PaletteInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := r.namesLocked()
+	infos := make([]PaletteInfo, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, newPaletteInfo(name, r.palettes[name]))
+	}
+	return infos
+}
+
+// LoadFile registers the palettes defined in a .json or .toml file.
+// If a palette in the file has no name, the file name (without extension) is used.
+func (r *PaletteRegistry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read palette file: %w", err)
+	}
+
+	var file paletteFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	default:
+		return fmt.Errorf("unsupported palette file type %q (expected .json or .toml)", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse palette file %s: %w", path, err)
+	}
+
+	entries := file.Palettes
+	if file.Chars != "" {
+		if file.Name == "" {
+			file.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		entries = append(entries, paletteFile{Name: file.Name, Chars: file.Chars})
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("palette file %s does not define any palettes", path)
+	}
+
+	for _, entry := range entries {
+		if err := r.Register(entry.Name, entry.Chars); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// LoadDir registers every .json and .toml palette file in dir.
+// Other files are ignored; subdirectories are not searched.
+func (r *PaletteRegistry) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read palette directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".toml":
+			if err := r.LoadFile(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve returns the character ramp to use for mapping.
+// Inline chars take precedence over the palette name; an empty name means PaletteNormal.
+func (r *PaletteRegistry) Resolve(name, chars string) (string, error) {
+	if chars != "" {
+		if err := ValidatePaletteChars(chars); err != nil {
+			return "", err
+		}
+		return chars, nil
+	}
+	if name == "" {
+		name = PaletteNormal
+	}
+	return r.Lookup(name)
+}
+
+func (r *PaletteRegistry) namesLocked() []string {
+	names := make([]string, 0, len(r.palettes))
+	for name := range r.palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newPaletteInfo(name, chars string) PaletteInfo {
+	glyphs := utf8.RuneCountInString(chars)
+	return PaletteInfo{
+		Name:       name,
+		Chars:      chars,
+		GlyphCount: glyphs,
+		MultiByte:  len(chars) != glyphs,
+	}
+}
+
+// RegisterPalette adds a palette to DefaultPalettes
+func RegisterPalette(name, chars string) error {
+	return DefaultPalettes.Register(name, chars)
+}
+
+// ResolvePalette resolves a palette name or inline character ramp using DefaultPalettes
+func ResolvePalette(name, chars string) (string, error) {
+	return DefaultPalettes.Resolve(name, chars)
+}
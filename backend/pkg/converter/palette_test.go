@@ -0,0 +1,156 @@
+package converter
+
+import (
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPaletteRegistry(t *testing.T) {
+	r := NewPaletteRegistry()
+	if err := r.Register(" blocks ", "░▒▓█"); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr error
+	}{
+		{name: PaletteNormal, want: " .:-=+*#%@"},
+		{name: PaletteDense, want: ".oO0@#"},
+		{name: "blocks", want: "░▒▓█"},
+		{name: "missing", wantErr: ErrUnknownPalette},
+		{name: "", wantErr: ErrUnknownPalette},
+	}
+	for _, tt := range tests {
+		got, err := r.Lookup(tt.name)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Lookup(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+		} else if got != tt.want {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	info, err := r.Info("blocks")
+	if err != nil {
+		t.Fatalf("Info returned error: %v", err)
+	}
+	if want := (PaletteInfo{Name: "blocks", Chars: "░▒▓█", GlyphCount: 4, MultiByte: true}); info != want {
+		t.Errorf("Info = %+v, want %+v", info, want)
+	}
+}
+
+func TestPaletteRegistryRegisterErrors(t *testing.T) {
+	tests := []struct {
+		name, chars string
+	}{
+		{"", "abc"},
+		{"  ", "abc"},
+		{"empty", ""},
+		{"newline", "a\nb"},
+		{"tab", "a\tb"},
+		{"invalid", "a\xffb"},
+	}
+	r := NewPaletteRegistry()
+	for _, tt := range tests {
+		if err := r.Register(tt.name, tt.chars); err == nil {
+			t.Errorf("Register(%q, %q) succeeded, want an error", tt.name, tt.chars)
+		}
+	}
+}
+
+func TestPaletteRegistryResolve(t *testing.T) {
+	r := NewPaletteRegistry()
+	tests := []struct {
+		name, chars string
+		want        string
+		wantErr     bool
+	}{
+		{name: "", chars: "", want: " .:-=+*#%@"},
+		{name: PaletteSparse, chars: "", want: " .'`^\""},
+		{name: PaletteSparse, chars: " #", want: " #"},
+		{name: "missing", chars: " #", want: " #"},
+		{name: "missing", chars: "", wantErr: true},
+		{name: "", chars: "a\nb", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := r.Resolve(tt.name, tt.chars)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Resolve(%q, %q) error = %v, want error %v", tt.name, tt.chars, err, tt.wantErr)
+		} else if got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.name, tt.chars, got, tt.want)
+		}
+	}
+}
+
+func TestPaletteRegistryLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"single.json": `{"chars": " .o"}`,
+		"named.json":  `{"name": "dots", "chars": " .:"}`,
+		"many.toml":   "[[palettes]]\nname = \"ab\"\nchars = \"ab\"\n\n[[palettes]]\nname = \"cd\"\nchars = \"cd\"\n",
+		"notes.txt":   "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewPaletteRegistry()
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir returned error: %v", err)
+	}
+	var names []string
+	for _, info := range r.List() {
+		names = append(names, info.Name)
+	}
+	want := []string{"ab", "cd", PaletteDense, "dots", PaletteNormal, "single", PaletteSparse, PaletteUnicode}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("registered palettes = %v, want %v", names, want)
+	}
+}
+
+func TestPaletteRegistryLoadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"broken.json": `{"chars": `,
+		"empty.json":  `{}`,
+		"bad.toml":    "name = \"bad\"\nchars = \"\"\n[[palettes]]\nname = \"\"\nchars = \"x\"\n",
+		"palette.txt": " .o",
+	}
+	r := NewPaletteRegistry()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.LoadFile(path); err == nil {
+			t.Errorf("LoadFile(%s) succeeded, want an error", name)
+		}
+	}
+	if err := r.LoadFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("LoadFile of a missing file succeeded, want an error")
+	}
+}
+
+func TestConvertToASCIIUnknownPalette(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.Pix[1] = 255
+	if got, err := ConvertToASCII(img, PaletteDense); err != nil || got != ".#\n" {
+		t.Errorf("ConvertToASCII(dense) = %q, %v, want %q", got, err, ".#\n")
+	}
+	if _, err := ConvertToASCII(img, "missing"); !errors.Is(err, ErrUnknownPalette) {
+		t.Errorf("ConvertToASCII(missing) error = %v, want ErrUnknownPalette", err)
+	}
+	if _, err := ConvertToASCIIWithColor(img, "missing"); !errors.Is(err, ErrUnknownPalette) {
+		t.Errorf("ConvertToASCIIWithColor(missing) error = %v, want ErrUnknownPalette", err)
+	}
+	if _, err := ConvertToASCIIWithColorStructured(img, "missing"); !errors.Is(err, ErrUnknownPalette) {
+		t.Errorf("ConvertToASCIIWithColorStructured(missing) error = %v, want ErrUnknownPalette", err)
+	}
+}
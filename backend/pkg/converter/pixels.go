@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"runtime"
+	"sync"
+)
+
+// minRowsPerBand keeps small images on one goroutine, where the overhead of
+// splitting would outweigh the work
+const minRowsPerBand = 16
+
+// pixelReader returns the alpha-premultiplied 16-bit color at (x, y), exactly as
+// img.At(x, y).RGBA() would
+type pixelReader func(x, y int) (r, g, b, a uint32)
+
+// newPixelReader returns a pixelReader that reads the pixel buffers of the common
+// decoder and resizer output types directly, avoiding the interface call and the
+// color allocation of img.At. Other image types fall back to img.At.
+func newPixelReader(img image.Image) pixelReader {
+	switch src := img.(type) {
+	case *image.Gray:
+		return func(x, y int) (r, g, b, a uint32) {
+			v := uint32(src.Pix[src.PixOffset(x, y)])
+			v |= v << 8
+			return v, v, v, 0xffff
+		}
+	case *image.RGBA:
+		return func(x, y int) (r, g, b, a uint32) {
+			p := src.Pix[src.PixOffset(x, y):]
+			r, g, b, a = uint32(p[0]), uint32(p[1]), uint32(p[2]), uint32(p[3])
+			return r | r<<8, g | g<<8, b | b<<8, a | a<<8
+		}
+	case *image.NRGBA:
+		return func(x, y int) (r, g, b, a uint32) {
+			p := src.Pix[src.PixOffset(x, y):]
+			return color.NRGBA{R: p[0], G: p[1], B: p[2], A: p[3]}.RGBA()
+		}
+	case *image.YCbCr:
+		return func(x, y int) (r, g, b, a uint32) {
+			yi, ci := src.YOffset(x, y), src.COffset(x, y)
+			return color.YCbCr{Y: src.Y[yi], Cb: src.Cb[ci], Cr: src.Cr[ci]}.RGBA()
+		}
+	default:
+		return func(x, y int) (r, g, b, a uint32) {
+			return img.At(x, y).RGBA()
+		}
+	}
+}
+
+// glyphTable maps every brightness to its glyph, so per-pixel mapping is a single lookup
+// instead of a BrightnessToChar call
+func glyphTable(charPalette string) *[256]string {
+	var table [256]string
+	for i := range table {
+		table[i] = BrightnessToChar(uint8(i), charPalette)
+	}
+	return &table
+}
+
+// parallelRows splits the rows [minY, maxY) into contiguous bands, one per GOMAXPROCS,
+// and calls fn for each band concurrently. It returns the number of bands once all calls
+// have finished; band indexes run from 0 in row order.
+func parallelRows(minY, maxY int, fn func(band, y0, y1 int)) int {
+	rows := maxY - minY
+	bands := runtime.GOMAXPROCS(0)
+	if limit := rows / minRowsPerBand; bands > limit {
+		bands = limit
+	}
+	if bands <= 1 {
+		fn(0, minY, maxY)
+		return 1
+	}
+
+	var wg sync.WaitGroup
+	for band := 0; band < bands; band++ {
+		y0 := minY + rows*band/bands
+		y1 := minY + rows*(band+1)/bands
+		wg.Add(1)
+		go func(band, y0, y1 int) {
+			defer wg.Done()
+			fn(band, y0, y1)
+		}(band, y0, y1)
+	}
+	wg.Wait()
+	return bands
+}
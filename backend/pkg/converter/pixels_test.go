@@ -0,0 +1,201 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// genericImage hides the concrete type of an image, so pixels are read through
+// image.Image.At as they were before the direct buffer readers
+type genericImage struct {
+	image.Image
+}
+
+// benchColor is a deterministic photo-like color at (x, y): smooth gradients with a
+// little pseudo-random texture
+func benchColor(x, y int) color.NRGBA {
+	noise := uint8((x*7919 + y*104729) % 23)
+	return color.NRGBA{
+		R: uint8(x*255/1024) + noise,
+		G: uint8(y*255/768) + noise,
+		B: uint8((x+y)*255/1792) + noise,
+		A: 255,
+	}
+}
+
+// benchSources returns a w x h test image as each source type with a direct reader, plus
+// "generic" for the image.Image.At path they replaced
+func benchSources(w, h int) []struct {
+	name string
+	img  image.Image
+} {
+	bounds := image.Rect(0, 0, w, h)
+	rgba := image.NewRGBA(bounds)
+	nrgba := image.NewNRGBA(bounds)
+	ycbcr := image.NewYCbCr(bounds, image.YCbCrSubsampleRatio420)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := benchColor(x, y)
+			rgba.Set(x, y, c)
+			nrgba.SetNRGBA(x, y, c)
+			yy, cb, cr := color.RGBToYCbCr(c.R, c.G, c.B)
+			ycbcr.Y[ycbcr.YOffset(x, y)] = yy
+			ycbcr.Cb[ycbcr.COffset(x, y)] = cb
+			ycbcr.Cr[ycbcr.COffset(x, y)] = cr
+		}
+	}
+	return []struct {
+		name string
+		img  image.Image
+	}{
+		{"generic", genericImage{rgba}},
+		{"rgba", rgba},
+		{"nrgba", nrgba},
+		{"ycbcr", ycbcr},
+	}
+}
+
+// testSources returns a w x h test image as each source type with a direct reader, with
+// partial alpha in the NRGBA image
+func testSources(w, h int) []struct {
+	name string
+	img  image.Image
+} {
+	bounds := image.Rect(0, 0, w, h)
+	rgba := image.NewRGBA(bounds)
+	nrgba := image.NewNRGBA(bounds)
+	ycbcr := image.NewYCbCr(bounds, image.YCbCrSubsampleRatio420)
+	gray := image.NewGray(bounds)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := benchColor(x*13, y*11)
+			rgba.Set(x, y, c)
+			nrgba.SetNRGBA(x, y, color.NRGBA{R: c.R, G: c.G, B: c.B, A: uint8(x * y * 7)})
+			yy, cb, cr := color.RGBToYCbCr(c.R, c.G, c.B)
+			ycbcr.Y[ycbcr.YOffset(x, y)] = yy
+			ycbcr.Cb[ycbcr.COffset(x, y)] = cb
+			ycbcr.Cr[ycbcr.COffset(x, y)] = cr
+			gray.SetGray(x, y, color.Gray{Y: c.G})
+		}
+	}
+	return []struct {
+		name string
+		img  image.Image
+	}{
+		{"rgba", rgba},
+		{"nrgba", nrgba},
+		{"ycbcr", ycbcr},
+		{"gray", gray},
+	}
+}
+
+// The direct pixel readers and the parallel row bands must give exactly the output of
+// reading every pixel through image.Image.At on one goroutine
+func TestDirectPixelReaders(t *testing.T) {
+	charPalette, _ := GetPalette(PaletteUnicode)
+	render := func(img image.Image) (*image.Gray, string, ColoredASCII) {
+		gray := ConvertToGrayscale(img).(*image.Gray)
+		return gray, ConvertToASCIIWithRamp(gray, charPalette), ConvertToASCIIWithColorStructuredRamp(img, charPalette)
+	}
+
+	for _, source := range testSources(67, 53) {
+		var wantGray *image.Gray
+		var wantText string
+		var wantColored ColoredASCII
+		func() {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+			wantGray, _, wantColored = render(genericImage{source.img})
+			wantText = ConvertToASCIIWithRamp(genericImage{wantGray}, charPalette)
+		}()
+
+		// Force several row bands even on a single CPU
+		var gray *image.Gray
+		var text string
+		var colored ColoredASCII
+		func() {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+			gray, text, colored = render(source.img)
+		}()
+		if !reflect.DeepEqual(gray.Pix, wantGray.Pix) {
+			t.Errorf("%s: ConvertToGrayscale differs from the image.Image.At path", source.name)
+		}
+		if text != wantText {
+			t.Errorf("%s: ConvertToASCIIWithRamp differs from the image.Image.At path", source.name)
+		}
+		if !reflect.DeepEqual(colored, wantColored) {
+			t.Errorf("%s: ConvertToASCIIWithColorStructuredRamp differs from the image.Image.At path", source.name)
+		}
+	}
+}
+
+func TestBrightnessToChar(t *testing.T) {
+	tests := []struct {
+		brightness uint8
+		palette    string
+		want       string
+	}{
+		{0, " .:-=+*#%@", " "},
+		{128, " .:-=+*#%@", "="},
+		{255, " .:-=+*#%@", "@"},
+		{0, "░▒▓█", "░"},
+		{170, "░▒▓█", "▓"},
+		{255, "░▒▓█", "█"},
+		{255, "", "@"},
+		{100, "x", "x"},
+	}
+	for _, tt := range tests {
+		if got := BrightnessToChar(tt.brightness, tt.palette); got != tt.want {
+			t.Errorf("BrightnessToChar(%d, %q) = %q, want %q", tt.brightness, tt.palette, got, tt.want)
+		}
+	}
+}
+
+// Run with -cpu 1 to compare the readers alone, without the parallel row bands
+
+func BenchmarkConvertToGrayscale(b *testing.B) {
+	for _, source := range benchSources(1024, 768) {
+		b.Run(source.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ConvertToGrayscale(source.img)
+			}
+		})
+	}
+}
+
+// ConvertToASCII only reads grayscale images, so it is measured on *image.Gray and on the
+// same pixels read through image.Image.At
+func BenchmarkConvertToASCII(b *testing.B) {
+	gray := ConvertToGrayscale(benchSources(200, 100)[1].img)
+	charPalette, _ := GetPalette(PaletteNormal)
+	sources := []struct {
+		name string
+		img  image.Image
+	}{
+		{"generic", genericImage{gray}},
+		{"gray", gray},
+	}
+	for _, source := range sources {
+		b.Run(source.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ConvertToASCIIWithRamp(source.img, charPalette)
+			}
+		})
+	}
+}
+
+func BenchmarkConvertToASCIIWithColorStructured(b *testing.B) {
+	charPalette, _ := GetPalette(PaletteNormal)
+	for _, source := range benchSources(200, 100) {
+		b.Run(source.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ConvertToASCIIWithColorStructuredRamp(source.img, charPalette)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Raster image formats
+const (
+	RasterPNG  = "png"
+	RasterJPEG = "jpeg"
+)
+
+// maxRasterScale limits the cell size and padding to this multiple of the font's cell,
+// keeping images to a sensible size
+const maxRasterScale = 8
+
+// maxRasterPixels limits the size of a drawn image or video frame, which takes 4 bytes per
+// pixel while it is drawn
+const maxRasterPixels = 40_000_000
+
+// ErrRasterTooLarge is returned when the art would be drawn into an image bigger than
+// maxRasterPixels
+var ErrRasterTooLarge = errors.New("image too large")
+
+// RasterOptions controls how ASCII art is drawn into an image with the embedded bitmap font
+// (see RasterizeGlyph), so the result looks the same everywhere and needs no system fonts
+type RasterOptions struct {
+	// Size of one character cell in pixels. Glyphs are scaled to the cell with
+	// nearest-neighbour sampling, so multiples of FontCellWidth x FontCellHeight stay crisp.
+	CellWidth  int
+	CellHeight int
+	Foreground RGB    // Glyph color of plain text output
+	Background RGB    // Page color, also shown through transparent cells
+	Padding    int    // Margin around the art in pixels (at most 56)
+	Format     string // RasterPNG (default) or RasterJPEG
+	Quality    int    // JPEG quality, 1-100
+}
+
+// DefaultRasterOptions returns white glyphs at the font's native 7x13 cell size on black, as PNG
+func DefaultRasterOptions() RasterOptions {
+	return RasterOptions{
+		CellWidth:  FontCellWidth,
+		CellHeight: FontCellHeight,
+		Foreground: RGB{R: 255, G: 255, B: 255},
+		Padding:    FontCellWidth,
+		Format:     RasterPNG,
+		Quality:    90,
+	}
+}
+
+// Validate checks the cell size, padding, format and JPEG quality
+func (o RasterOptions) Validate() error {
+	if o.CellWidth <= 0 || o.CellHeight <= 0 {
+		return fmt.Errorf("cell size must be positive")
+	}
+	if o.CellWidth > maxRasterScale*FontCellWidth || o.CellHeight > maxRasterScale*FontCellHeight {
+		return fmt.Errorf("cell size must be at most %dx%d", maxRasterScale*FontCellWidth, maxRasterScale*FontCellHeight)
+	}
+	if o.Padding < 0 {
+		return fmt.Errorf("padding must not be negative")
+	}
+	if o.Padding > maxRasterScale*FontCellWidth {
+		return fmt.Errorf("padding must be at most %d", maxRasterScale*FontCellWidth)
+	}
+	switch o.Format {
+	case "", RasterPNG:
+	case RasterJPEG:
+		if o.Quality < 1 || o.Quality > 100 {
+			return fmt.Errorf("invalid JPEG quality %d (expected 1-100)", o.Quality)
+		}
+	default:
+		return fmt.Errorf("unknown image format %q (valid: %s, %s)", o.Format, RasterPNG, RasterJPEG)
+	}
+	return nil
+}
+
+// RasterizeASCII draws ASCII art into an image, one opts.CellWidth x opts.CellHeight cell per
+// character. Colored characters are drawn in their own color over their background, if any;
+// characters without a glyph in the embedded font are left blank. Art that would make an
+// image of more than 40 million pixels returns ErrRasterTooLarge.
+func RasterizeASCII(asciiArt string, coloredASCII *ColoredASCII, opts RasterOptions) (*image.RGBA, error) {
+	lines := rasterLines(asciiArt, coloredASCII, opts.Foreground)
+	bounds, err := rasterBounds(opts, lines)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(bounds)
+	drawRasterLines(img, lines, opts)
+	return img, nil
+}
+
+// rasterBounds returns the image size that fits the widest and tallest of frames, or
+// ErrRasterTooLarge if it has more than maxRasterPixels
+func rasterBounds(opts RasterOptions, frames ...[][]ColoredChar) (image.Rectangle, error) {
+	columns, rows := 0, 0
+	for _, lines := range frames {
+		rows = max(rows, len(lines))
+		for _, line := range lines {
+			columns = max(columns, len(line))
+		}
+	}
+	width, height := columns*opts.CellWidth+2*opts.Padding, rows*opts.CellHeight+2*opts.Padding
+	if width*height > maxRasterPixels {
+		return image.Rectangle{}, fmt.Errorf("%w: %dx%d pixels (at most %d million; use a smaller width or cell size)",
+			ErrRasterTooLarge, width, height, maxRasterPixels/1_000_000)
+	}
+	return image.Rect(0, 0, width, height), nil
+}
+
+// drawRasterLines fills img with the page color and draws lines from its top-left corner
+func drawRasterLines(img *image.RGBA, lines [][]ColoredChar, opts RasterOptions) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = opts.Background.R, opts.Background.G, opts.Background.B, 255
+	}
+
+	parallelRows(0, len(lines), func(_, row0, row1 int) {
+		for row := row0; row < row1; row++ {
+			for column, char := range lines[row] {
+				x0 := opts.Padding + column*opts.CellWidth
+				y0 := opts.Padding + row*opts.CellHeight
+				drawRasterCell(img, x0, y0, char, opts)
+			}
+		}
+	})
+}
+
+// rasterLines returns the cells of colored output, or of plain text drawn in foreground
+func rasterLines(asciiArt string, coloredASCII *ColoredASCII, foreground RGB) [][]ColoredChar {
+	if coloredASCII != nil {
+		return coloredASCII.Lines
+	}
+	asciiArt = strings.TrimRight(asciiArt, "\n")
+	if asciiArt == "" {
+		return nil
+	}
+	var lines [][]ColoredChar
+	for _, text := range strings.Split(asciiArt, "\n") {
+		line := make([]ColoredChar, 0, utf8.RuneCountInString(text))
+		for _, r := range text {
+			line = append(line, ColoredChar{Char: string(r), R: foreground.R, G: foreground.G, B: foreground.B})
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// drawRasterCell fills the cell whose top-left pixel is (x0, y0) with the character's
+// background and blends its glyph over it
+func drawRasterCell(img *image.RGBA, x0, y0 int, char ColoredChar, opts RasterOptions) {
+	if char.Transparent {
+		return
+	}
+	r, _ := utf8.DecodeRuneInString(char.Char)
+	bitmap, _ := RasterizeGlyph(r)
+
+	for y := 0; y < opts.CellHeight; y++ {
+		offset := img.PixOffset(x0, y0+y)
+		fy := y * FontCellHeight / opts.CellHeight
+		for x := 0; x < opts.CellWidth; x++ {
+			pix := img.Pix[offset : offset+3 : offset+3]
+			if char.Background != nil {
+				pix[0], pix[1], pix[2] = char.Background.R, char.Background.G, char.Background.B
+			}
+			if coverage := uint32(bitmap.At(x*FontCellWidth/opts.CellWidth, fy)); coverage > 0 {
+				pix[0] = blendChannel(pix[0], char.R, coverage)
+				pix[1] = blendChannel(pix[1], char.G, coverage)
+				pix[2] = blendChannel(pix[2], char.B, coverage)
+			}
+			offset += 4
+		}
+	}
+}
+
+// blendChannel mixes fg over bg with coverage 0-255
+func blendChannel(bg, fg uint8, coverage uint32) uint8 {
+	return uint8((uint32(bg)*(255-coverage) + uint32(fg)*coverage + 127) / 255)
+}
+
+// WriteRaster draws ASCII art with RasterizeASCII and encodes it to w as PNG or JPEG
+func WriteRaster(w io.Writer, asciiArt string, coloredASCII *ColoredASCII, opts RasterOptions) error {
+	img, err := RasterizeASCII(asciiArt, coloredASCII, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Format == RasterJPEG {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: opts.Quality})
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestRasterOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*RasterOptions)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(o *RasterOptions) {}},
+		{name: "largest cell", modify: func(o *RasterOptions) { o.CellWidth, o.CellHeight = 8*FontCellWidth, 8*FontCellHeight }},
+		{name: "no padding", modify: func(o *RasterOptions) { o.Padding = 0 }},
+		{name: "largest padding", modify: func(o *RasterOptions) { o.Padding = 8 * FontCellWidth }},
+		{name: "jpeg", modify: func(o *RasterOptions) { o.Format, o.Quality = RasterJPEG, 1 }},
+		{name: "zero cell width", modify: func(o *RasterOptions) { o.CellWidth = 0 }, wantErr: true},
+		{name: "negative cell height", modify: func(o *RasterOptions) { o.CellHeight = -13 }, wantErr: true},
+		{name: "cell too wide", modify: func(o *RasterOptions) { o.CellWidth = 8*FontCellWidth + 1 }, wantErr: true},
+		{name: "cell too tall", modify: func(o *RasterOptions) { o.CellHeight = 8*FontCellHeight + 1 }, wantErr: true},
+		{name: "negative padding", modify: func(o *RasterOptions) { o.Padding = -1 }, wantErr: true},
+		{name: "padding too large", modify: func(o *RasterOptions) { o.Padding = 8*FontCellWidth + 1 }, wantErr: true},
+		{name: "jpeg quality zero", modify: func(o *RasterOptions) { o.Format, o.Quality = RasterJPEG, 0 }, wantErr: true},
+		{name: "jpeg quality too high", modify: func(o *RasterOptions) { o.Format, o.Quality = RasterJPEG, 101 }, wantErr: true},
+		{name: "unknown format", modify: func(o *RasterOptions) { o.Format = "webp" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		opts := DefaultRasterOptions()
+		tt.modify(&opts)
+		if err := opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRasterizeASCII(t *testing.T) {
+	opts := DefaultRasterOptions()
+	opts.Background = RGB{B: 40}
+	red := RGB{R: 255}
+
+	tests := []struct {
+		name          string
+		text          string
+		colored       *ColoredASCII
+		width, height int
+		cell          color.RGBA // Centre of the first cell
+	}{
+		{name: "plain", text: "█b\nc\n", width: 2*7 + 14, height: 2*13 + 14, cell: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+		{name: "blank", text: " ", width: 7 + 14, height: 13 + 14, cell: color.RGBA{B: 40, A: 255}},
+		{
+			name:    "colored",
+			colored: &ColoredASCII{Lines: [][]ColoredChar{{{Char: " ", Background: &red}, {Char: "x", G: 255}, {Char: "y"}}}},
+			width:   3*7 + 14, height: 13 + 14, cell: color.RGBA{R: 255, A: 255},
+		},
+	}
+	for _, tt := range tests {
+		img, err := RasterizeASCII(tt.text, tt.colored, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if size := img.Bounds().Size(); size.X != tt.width || size.Y != tt.height {
+			t.Errorf("%s: size %v, want %dx%d", tt.name, size, tt.width, tt.height)
+		}
+		if got := img.RGBAAt(0, 0); got != (color.RGBA{B: 40, A: 255}) {
+			t.Errorf("%s: padding %v, want the background", tt.name, got)
+		}
+		if got := img.RGBAAt(opts.Padding+3, opts.Padding+6); got != tt.cell {
+			t.Errorf("%s: first cell %v, want %v", tt.name, got, tt.cell)
+		}
+	}
+}
+
+func TestRasterizeASCIITooLarge(t *testing.T) {
+	opts := DefaultRasterOptions()
+	opts.CellWidth, opts.CellHeight = 8*FontCellWidth, 8*FontCellHeight
+
+	// 100,000 columns of 56 pixels by one 104-pixel row is over 40 million pixels
+	_, err := RasterizeASCII(strings.Repeat("#", 100_000), nil, opts)
+	if !errors.Is(err, ErrRasterTooLarge) {
+		t.Fatalf("got error %v, want ErrRasterTooLarge", err)
+	}
+	if err := WriteRaster(&bytes.Buffer{}, strings.Repeat("#", 100_000), nil, opts); !errors.Is(err, ErrRasterTooLarge) {
+		t.Errorf("WriteRaster: got error %v, want ErrRasterTooLarge", err)
+	}
+}
+
+func TestWriteRaster(t *testing.T) {
+	for _, format := range []string{RasterPNG, RasterJPEG} {
+		opts := DefaultRasterOptions()
+		opts.Format = format
+		var buf bytes.Buffer
+		if err := WriteRaster(&buf, "ab\ncd\n", nil, opts); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		img, decoded, err := image.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s: decoding: %v", format, err)
+		}
+		if decoded != format || img.Bounds().Size() != (image.Point{X: 2*7 + 14, Y: 2*13 + 14}) {
+			t.Errorf("%s: got a %s image of %v", format, decoded, img.Bounds().Size())
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/nfnt/resize"
+)
+
+// Resampling filters
+const (
+	ResampleNearest  = "nearest"  // Nearest neighbour: fastest, blocky and aliased on downscales
+	ResampleBilinear = "bilinear" // Linear interpolation between neighbouring pixels
+	ResampleBicubic  = "bicubic"  // Catmull-Rom cubic: sharper than bilinear
+	ResampleLanczos  = "lanczos"  // Lanczos3 (default): sharpest, but rings around hard edges
+	ResampleBox      = "box"      // Area average of every source pixel a target pixel covers
+)
+
+// ValidateResample checks that filter is a known resampling filter ("" means ResampleLanczos)
+func ValidateResample(filter string) error {
+	switch filter {
+	case "", ResampleNearest, ResampleBilinear, ResampleBicubic, ResampleLanczos, ResampleBox:
+		return nil
+	default:
+		return fmt.Errorf("unknown resampling filter %q (valid: %s, %s, %s, %s, %s)", filter, ResampleNearest, ResampleBilinear, ResampleBicubic, ResampleLanczos, ResampleBox)
+	}
+}
+
+// Resample scales img to width x height pixels with the given filter ("" means ResampleLanczos).
+// As with resize.Resize, a zero width or height is derived from the image's aspect ratio.
+// The box filter weights every source pixel by how much of it a target pixel covers, which
+// avoids both aliasing and ringing on large downscales; the others use nfnt/resize.
+func Resample(img image.Image, width, height int, filter string) image.Image {
+	switch filter {
+	case ResampleNearest:
+		return resize.Resize(uint(width), uint(height), img, resize.NearestNeighbor)
+	case ResampleBilinear:
+		return resize.Resize(uint(width), uint(height), img, resize.Bilinear)
+	case ResampleBicubic:
+		return resize.Resize(uint(width), uint(height), img, resize.Bicubic)
+	case ResampleBox:
+		return resampleRGBA(img, width, height, filter)
+	default:
+		return resize.Resize(uint(width), uint(height), img, resize.Lanczos3)
+	}
+}
+
+// ResampleGray scales img to width x height pixels and converts it to grayscale with the
+// given model (see GrayFunc) in the same pass: source pixels are reduced to their brightness
+// as they are read, so a large photo is never copied at full resolution. Only the source rows
+// and columns the filter needs are read. Zero sizes are handled as in Resample.
+func ResampleGray(img image.Image, width, height int, filter, model string) *image.Gray {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth == 0 || srcHeight == 0 {
+		return image.NewGray(image.Rect(0, 0, width, height))
+	}
+	width, height = resampleSize(srcWidth, srcHeight, width, height)
+	out := image.NewGray(image.Rect(0, 0, width, height))
+
+	columns := resampleSpans(srcWidth, width, filter)
+	rows := resampleSpans(srcHeight, height, filter)
+	neededColumns := spannedPixels(columns, srcWidth)
+	neededRows := spannedPixels(rows, srcHeight)
+
+	// Horizontal pass: one filtered row of luminance per needed source row
+	readRow := newLuminanceReader(img, model)
+	temp := make([]float32, srcHeight*width)
+	parallelRows(0, srcHeight, func(_, y0, y1 int) {
+		luminance := make([]float32, srcWidth)
+		for y := y0; y < y1; y++ {
+			if !neededRows[y] {
+				continue
+			}
+			readRow(bounds.Min.Y+y, luminance, neededColumns)
+			filtered := temp[y*width : (y+1)*width]
+			for x, span := range columns {
+				filtered[x] = span.apply(luminance)
+			}
+		}
+	})
+
+	// Vertical pass over the filtered rows
+	parallelRows(0, height, func(_, y0, y1 int) {
+		sum := make([]float32, width)
+		for y := y0; y < y1; y++ {
+			rows[y].applyRows(temp, width, sum)
+			offset := out.PixOffset(0, y)
+			for x, v := range sum {
+				out.Pix[offset+x] = clampChannel(float64(v))
+			}
+		}
+	})
+	return out
+}
+
+// luminanceReader fills row[x] with the brightness of pixel (bounds.Min.X+x, y) for every
+// column marked in needed
+type luminanceReader func(y int, row []float32, needed []bool)
+
+// newLuminanceReader returns a luminanceReader for a grayscale model, without the rounding
+// of GrayFunc. Rec.601 reads the pixel buffers of the common image types directly, and for
+// *image.YCbCr uses the Y plane, which already holds that luminance. Every model leaves
+// gray pixels unchanged, so *image.Gray is always read directly.
+func newLuminanceReader(img image.Image, model string) luminanceReader {
+	minX := img.Bounds().Min.X
+	if gray, ok := grayModels[model]; ok && model != GrayRec601 {
+		if _, isGray := img.(*image.Gray); !isGray {
+			pixel := newPixelReader(img)
+			return func(y int, row []float32, needed []bool) {
+				for x := range row {
+					if needed[x] {
+						r, g, b, _ := pixel(minX+x, y)
+						row[x] = float32(gray(float64(r>>8), float64(g>>8), float64(b>>8)))
+					}
+				}
+			}
+		}
+	}
+
+	switch src := img.(type) {
+	case *image.Gray:
+		return func(y int, row []float32, needed []bool) {
+			pix := src.Pix[src.PixOffset(minX, y):]
+			for x := range row {
+				if needed[x] {
+					row[x] = float32(pix[x])
+				}
+			}
+		}
+	case *image.YCbCr:
+		return func(y int, row []float32, needed []bool) {
+			pix := src.Y[src.YOffset(minX, y):]
+			for x := range row {
+				if needed[x] {
+					row[x] = float32(pix[x])
+				}
+			}
+		}
+	case *image.RGBA:
+		return func(y int, row []float32, needed []bool) {
+			pix := src.Pix[src.PixOffset(minX, y):]
+			for x := range row {
+				if needed[x] {
+					p := pix[4*x : 4*x+3]
+					row[x] = 0.299*float32(p[0]) + 0.587*float32(p[1]) + 0.114*float32(p[2])
+				}
+			}
+		}
+	case *image.NRGBA:
+		return func(y int, row []float32, needed []bool) {
+			pix := src.Pix[src.PixOffset(minX, y):]
+			for x := range row {
+				if needed[x] {
+					p := pix[4*x : 4*x+4]
+					row[x] = (0.299*float32(p[0]) + 0.587*float32(p[1]) + 0.114*float32(p[2])) * float32(p[3]) / 255
+				}
+			}
+		}
+	default:
+		pixel := newPixelReader(img)
+		return func(y int, row []float32, needed []bool) {
+			for x := range row {
+				if needed[x] {
+					r, g, b, _ := pixel(minX+x, y)
+					row[x] = 0.299*float32(r>>8) + 0.587*float32(g>>8) + 0.114*float32(b>>8)
+				}
+			}
+		}
+	}
+}
+
+// resampleRGBA scales img to width x height pixels with resampleSpans weights, averaging
+// alpha-premultiplied channels so transparent pixels do not bleed their color
+func resampleRGBA(img image.Image, width, height int, filter string) *image.RGBA {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth == 0 || srcHeight == 0 {
+		return image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	width, height = resampleSize(srcWidth, srcHeight, width, height)
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	columns := resampleSpans(srcWidth, width, filter)
+	rows := resampleSpans(srcHeight, height, filter)
+	neededRows := spannedPixels(rows, srcHeight)
+
+	// Horizontal pass into four planes of filtered channels, one row per needed source row
+	pixel := newPixelReader(img)
+	var temp [4][]float32
+	for ch := range temp {
+		temp[ch] = make([]float32, srcHeight*width)
+	}
+	parallelRows(0, srcHeight, func(_, y0, y1 int) {
+		var channels [4][]float32
+		for ch := range channels {
+			channels[ch] = make([]float32, srcWidth)
+		}
+		for y := y0; y < y1; y++ {
+			if !neededRows[y] {
+				continue
+			}
+			for x := 0; x < srcWidth; x++ {
+				r, g, b, a := pixel(bounds.Min.X+x, bounds.Min.Y+y)
+				channels[0][x], channels[1][x], channels[2][x], channels[3][x] = float32(r>>8), float32(g>>8), float32(b>>8), float32(a>>8)
+			}
+			for ch := range channels {
+				filtered := temp[ch][y*width : (y+1)*width]
+				for x, span := range columns {
+					filtered[x] = span.apply(channels[ch])
+				}
+			}
+		}
+	})
+
+	// Vertical pass; premultiplied color may not exceed alpha
+	parallelRows(0, height, func(_, y0, y1 int) {
+		var sums [4][]float32
+		for ch := range sums {
+			sums[ch] = make([]float32, width)
+		}
+		for y := y0; y < y1; y++ {
+			for ch := range sums {
+				rows[y].applyRows(temp[ch], width, sums[ch])
+			}
+			offset := out.PixOffset(0, y)
+			for x := 0; x < width; x++ {
+				alpha := clampChannel(float64(sums[3][x]))
+				for ch := 0; ch < 3; ch++ {
+					v := clampChannel(float64(sums[ch][x]))
+					if v > alpha {
+						v = alpha
+					}
+					out.Pix[offset+4*x+ch] = v
+				}
+				out.Pix[offset+4*x+3] = alpha
+			}
+		}
+	})
+	return out
+}
+
+// resampleSpan lists the weights of the source pixels [start, start+len(weights)) that
+// make up one target pixel. The weights sum to 1.
+type resampleSpan struct {
+	start   int
+	weights []float32
+}
+
+// apply returns the weighted sum of the span's pixels in values
+func (s resampleSpan) apply(values []float32) float32 {
+	var sum float32
+	for i, w := range s.weights {
+		sum += w * values[s.start+i]
+	}
+	return sum
+}
+
+// applyRows sets sum to the weighted sum of the span's rows of a row-major plane of the
+// given width
+func (s resampleSpan) applyRows(plane []float32, width int, sum []float32) {
+	for x := range sum {
+		sum[x] = 0
+	}
+	for i, w := range s.weights {
+		row := plane[(s.start+i)*width : (s.start+i+1)*width]
+		for x, v := range row {
+			sum[x] += w * v
+		}
+	}
+}
+
+// resampleSpans computes the source weights of each of dstSize target pixels along one
+// axis of srcSize source pixels. Kernels are widened by the scale factor when downscaling
+// so every source pixel contributes, and cut off at the image border.
+func resampleSpans(srcSize, dstSize int, filter string) []resampleSpan {
+	scale := float64(srcSize) / float64(dstSize)
+	spans := make([]resampleSpan, dstSize)
+
+	for i := range spans {
+		var lo, hi float64
+		var weight func(x float64) float64
+		center := (float64(i) + 0.5) * scale
+
+		switch filter {
+		case ResampleNearest:
+			j := int(center)
+			if j >= srcSize {
+				j = srcSize - 1
+			}
+			spans[i] = resampleSpan{start: j, weights: []float32{1}}
+			continue
+		case ResampleBox:
+			// Weight each source pixel [j, j+1) by its overlap with [lo, hi)
+			lo, hi = float64(i)*scale, float64(i+1)*scale
+			if hi-lo < 1 {
+				lo, hi = center-0.5, center+0.5
+			}
+			weight = func(x float64) float64 {
+				return math.Max(0, math.Min(hi, x+0.5)-math.Max(lo, x-0.5))
+			}
+		default:
+			radius, kernel := resampleKernel(filter)
+			stretch := math.Max(scale, 1)
+			lo, hi = center-radius*stretch, center+radius*stretch
+			weight = func(x float64) float64 {
+				return kernel((x - center) / stretch)
+			}
+		}
+
+		start := int(math.Floor(lo))
+		if start < 0 {
+			start = 0
+		}
+		end := int(math.Ceil(hi))
+		if end > srcSize {
+			end = srcSize
+		}
+		if end <= start {
+			start, end = clampCoord(start, srcSize), clampCoord(start, srcSize)+1
+		}
+
+		weights := make([]float64, end-start)
+		var sum float64
+		for j := start; j < end; j++ {
+			weights[j-start] = weight(float64(j) + 0.5)
+			sum += weights[j-start]
+		}
+		span := resampleSpan{start: start, weights: make([]float32, len(weights))}
+		for k, w := range weights {
+			if sum != 0 {
+				span.weights[k] = float32(w / sum)
+			}
+		}
+		spans[i] = span
+	}
+	return spans
+}
+
+// resampleKernel returns the radius and weight function of an interpolating filter
+func resampleKernel(filter string) (float64, func(x float64) float64) {
+	switch filter {
+	case ResampleBilinear:
+		return 1, func(x float64) float64 {
+			return math.Max(0, 1-math.Abs(x))
+		}
+	case ResampleBicubic:
+		return 2, func(x float64) float64 {
+			x = math.Abs(x)
+			switch {
+			case x <= 1:
+				return x*x*(1.5*x-2.5) + 1
+			case x < 2:
+				return x*(x*(2.5-0.5*x)-4) + 2
+			default:
+				return 0
+			}
+		}
+	default:
+		return 3, func(x float64) float64 {
+			if x <= -3 || x >= 3 {
+				return 0
+			}
+			return sinc(x) * sinc(x/3)
+		}
+	}
+}
+
+// sinc is the normalized sinc function sin(πx)/(πx)
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// spannedPixels marks the source pixels that at least one span reads
+func spannedPixels(spans []resampleSpan, size int) []bool {
+	needed := make([]bool, size)
+	for _, span := range spans {
+		for i := range span.weights {
+			needed[span.start+i] = true
+		}
+	}
+	return needed
+}
+
+// resampleSize fills in a zero width or height from the source aspect ratio, the way
+// resize.Resize does
+func resampleSize(srcWidth, srcHeight, width, height int) (int, int) {
+	switch {
+	case width == 0 && height == 0:
+		return srcWidth, srcHeight
+	case width == 0:
+		width = int(0.7 + float64(srcWidth)*float64(height)/float64(srcHeight))
+	case height == 0:
+		height = int(0.7 + float64(srcHeight)*float64(width)/float64(srcWidth))
+	}
+	return width, height
+}
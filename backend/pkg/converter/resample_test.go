@@ -0,0 +1,38 @@
+package converter
+
+import "testing"
+
+var benchFilters = []string{ResampleNearest, ResampleBilinear, ResampleBicubic, ResampleLanczos, ResampleBox}
+
+// Both benchmarks shrink a 4000x3000 photo to the 100x37 pixels of a 100 character wide
+// conversion. Run with -cpu 1 for the cost on one core.
+
+func BenchmarkResample(b *testing.B) {
+	for _, source := range benchSources(4000, 3000) {
+		b.Run(source.name, func(b *testing.B) {
+			for _, filter := range benchFilters {
+				b.Run(filter, func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						Resample(source.img, 100, 37, filter)
+					}
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkResampleGray(b *testing.B) {
+	for _, source := range benchSources(4000, 3000) {
+		b.Run(source.name, func(b *testing.B) {
+			for _, filter := range benchFilters {
+				b.Run(filter, func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						ResampleGray(source.img, 100, 37, filter, GrayRec601)
+					}
+				})
+			}
+		})
+	}
+}
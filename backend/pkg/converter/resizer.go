@@ -1,9 +1,10 @@
 package converter
 
 import (
+	"fmt"
 	"image"
-
-	"github.com/nfnt/resize"
+	"image/color"
+	"image/draw"
 )
 
 // ResizeImage resizes an image to the target width while maintaining aspect ratio.
@@ -16,8 +17,16 @@ import (
 // Returns:
 //   - A resized image ready for ASCII conversion
 func ResizeImage(img image.Image, targetWidth int) image.Image {
+	width, height := resizeDimensions(img.Bounds(), targetWidth)
+
+	// Lanczos3 provides high-quality results, good for downscaling
+	// Other filters: see Resample
+	return Resample(img, width, height, ResampleLanczos)
+}
+
+// resizeDimensions returns the pixel size ResizeImage scales to, at most MaxBoxSize rows tall
+func resizeDimensions(bounds image.Rectangle, targetWidth int) (int, int) {
 	// Get original dimensions
-	bounds := img.Bounds()
 	originalWidth := bounds.Max.X - bounds.Min.X
 	originalHeight := bounds.Max.Y - bounds.Min.Y
 
@@ -33,10 +42,192 @@ func ResizeImage(img image.Image, targetWidth int) image.Image {
 	aspectRatio := 0.5
 	newHeight = int(float64(newHeight) * aspectRatio)
 
-	// Use the resize library with Lanczos3 interpolation
-	// Lanczos3 provides high-quality results, good for downscaling
-	// Other options: NearestNeighbor (fastest), Bilinear, Bicubic
-	resizedImg := resize.Resize(uint(targetWidth), uint(newHeight), img, resize.Lanczos3)
+	return targetWidth, min(newHeight, MaxBoxSize)
+}
+
+// ResizeImageToCells resizes an image so each of targetWidth output characters covers a
+// cellWidth x cellHeight pixel block. The cell shape provides the character aspect ratio
+// correction, so the result is exactly targetWidth*cellWidth pixels wide and a whole
+// number of cells tall.
+func ResizeImageToCells(img image.Image, targetWidth, cellWidth, cellHeight int) image.Image {
+	width, height := cellDimensions(img.Bounds(), targetWidth, cellWidth, cellHeight)
+	return Resample(img, width, height, ResampleLanczos)
+}
+
+// cellDimensions returns the pixel size ResizeImageToCells scales to, at most MaxBoxSize
+// cells tall
+func cellDimensions(bounds image.Rectangle, targetWidth, cellWidth, cellHeight int) (int, int) {
+	originalWidth := bounds.Max.X - bounds.Min.X
+	originalHeight := bounds.Max.Y - bounds.Min.Y
+
+	pixelWidth := targetWidth * cellWidth
+	scale := float64(pixelWidth) / float64(originalWidth)
+
+	// Round to the nearest whole number of cell rows (at least one)
+	rows := int(float64(originalHeight)*scale/float64(cellHeight) + 0.5)
+	rows = min(max(rows, 1), MaxBoxSize)
+
+	return pixelWidth, rows * cellHeight
+}
+
+// Fit modes for ResizeImageToBox, used when both a width and a height are given
+const (
+	FitContain = "contain" // Scale to fit inside the box and pad the rest
+	FitCover   = "cover"   // Scale to fill the box and crop the overflow
+	FitStretch = "stretch" // Scale each axis to the box, ignoring the image's aspect ratio
+)
+
+// DefaultCharAspect is the width-to-height ratio assumed for a terminal character
+const DefaultCharAspect = 0.5
+
+// Limits of BoxOptions
+const (
+	MaxBoxSize    = 1000 // Largest output width or height in characters, including derived ones
+	MinCharAspect = 0.1  // Narrowest character aspect ratio
+	MaxCharAspect = 10.0 // Widest character aspect ratio
+)
+
+// BoxOptions sets the output size in characters for ResizeImageToBox
+type BoxOptions struct {
+	Width      int         // Output width in characters, at most MaxBoxSize (0 derives it from Height)
+	Height     int         // Output height in characters, at most MaxBoxSize (0 derives it from Width)
+	CharAspect float64     // Character width divided by its height, MinCharAspect-MaxCharAspect (0 means DefaultCharAspect)
+	Fit        string      // How to fill the box when both Width and Height are set (default FitContain)
+	Padding    color.Color // Fill for FitContain padding (nil means transparent)
+	Resample   string      // Resampling filter (see Resample; default ResampleLanczos)
+	Grayscale  bool        // Resize and convert to grayscale in one pass (see ResampleGray)
+	GrayModel  string      // Grayscale model used with Grayscale (see GrayFunc)
+}
+
+// Validate checks the box size, aspect ratio and fit mode
+func (o BoxOptions) Validate() error {
+	if o.Width < 0 || o.Height < 0 {
+		return fmt.Errorf("width and height must not be negative")
+	}
+	if o.Width == 0 && o.Height == 0 {
+		return fmt.Errorf("width or height must be positive")
+	}
+	if o.Width > MaxBoxSize || o.Height > MaxBoxSize {
+		return fmt.Errorf("width and height must be at most %d", MaxBoxSize)
+	}
+	if o.CharAspect != 0 && !(o.CharAspect >= MinCharAspect && o.CharAspect <= MaxCharAspect) {
+		return fmt.Errorf("character aspect ratio must be between %g and %g", MinCharAspect, MaxCharAspect)
+	}
+	if err := ValidateResample(o.Resample); err != nil {
+		return err
+	}
+	if err := ValidateGrayModel(o.GrayModel); err != nil {
+		return err
+	}
+	switch o.Fit {
+	case "", FitContain, FitCover, FitStretch:
+		return nil
+	default:
+		return fmt.Errorf("unknown fit mode %q (valid: %s, %s, %s)", o.Fit, FitContain, FitCover, FitStretch)
+	}
+}
+
+// ResizeImageToBox resizes an image for output of opts.Width x opts.Height characters, where
+// each character covers a cellWidth x cellHeight pixel block. With only one dimension set
+// the other follows from the image's aspect ratio and opts.CharAspect. With both set, the
+// image is fitted to the box according to opts.Fit; contain padding is centered on whole cells.
+// With opts.Grayscale the result is an *image.Gray.
+func ResizeImageToBox(img image.Image, opts BoxOptions, cellWidth, cellHeight int) image.Image {
+	bounds := img.Bounds()
+	originalWidth := float64(bounds.Dx())
+	originalHeight := float64(bounds.Dy())
+
+	aspect := opts.CharAspect
+	if aspect == 0 {
+		aspect = DefaultCharAspect
+	}
+
+	// Character counts that keep the image's proportions for a given width or height, capped
+	// at MaxBoxSize so a very tall or wide image can't blow up the other dimension
+	rowsFor := func(cols int) int {
+		return min(atLeastOne(float64(cols)*aspect*originalHeight/originalWidth), MaxBoxSize)
+	}
+	colsFor := func(rows int) int {
+		return min(atLeastOne(float64(rows)*originalWidth/(aspect*originalHeight)), MaxBoxSize)
+	}
+	resizeTo := func(src image.Image, cols, rows int) image.Image {
+		return opts.resample(src, cols*cellWidth, rows*cellHeight)
+	}
+
+	switch {
+	case opts.Height == 0:
+		return resizeTo(img, opts.Width, rowsFor(opts.Width))
+	case opts.Width == 0:
+		return resizeTo(img, colsFor(opts.Height), opts.Height)
+	case opts.Fit == FitStretch:
+		return resizeTo(img, opts.Width, opts.Height)
+	case opts.Fit == FitCover:
+		// Crop the source to the box's proportions around its centre, then fill the box
+		crop := bounds
+		boxRatio := float64(opts.Width) * aspect / float64(opts.Height)
+		if originalWidth/originalHeight > boxRatio {
+			cropWidth := int(originalHeight*boxRatio + 0.5)
+			crop.Min.X += (bounds.Dx() - cropWidth) / 2
+			crop.Max.X = crop.Min.X + cropWidth
+		} else {
+			cropHeight := int(originalWidth/boxRatio + 0.5)
+			crop.Min.Y += (bounds.Dy() - cropHeight) / 2
+			crop.Max.Y = crop.Min.Y + cropHeight
+		}
+		return resizeTo(cropImage(img, crop), opts.Width, opts.Height)
+	}
+
+	// Contain: fit the limiting dimension, then pad to the full box
+	cols, rows := opts.Width, rowsFor(opts.Width)
+	if rows > opts.Height {
+		cols, rows = colsFor(opts.Height), opts.Height
+		if cols > opts.Width {
+			cols = opts.Width
+		}
+	}
+	resized := resizeTo(img, cols, rows)
+
+	padding := opts.Padding
+	if padding == nil {
+		padding = color.Transparent
+	}
+	var canvas draw.Image = image.NewNRGBA(image.Rect(0, 0, opts.Width*cellWidth, opts.Height*cellHeight))
+	if opts.Grayscale {
+		r, g, b, _ := padding.RGBA()
+		padding = color.Gray{Y: GrayFunc(opts.GrayModel)(r, g, b)}
+		canvas = image.NewGray(canvas.Bounds())
+	}
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(padding), image.Point{}, draw.Src)
+	offset := image.Pt((opts.Width-cols)/2*cellWidth, (opts.Height-rows)/2*cellHeight)
+	draw.Draw(canvas, resized.Bounds().Sub(resized.Bounds().Min).Add(offset), resized, resized.Bounds().Min, draw.Src)
+	return canvas
+}
+
+// resample scales img to width x height pixels with the configured filter, producing an
+// *image.Gray when Grayscale is set
+func (o BoxOptions) resample(img image.Image, width, height int) image.Image {
+	if o.Grayscale {
+		return ResampleGray(img, width, height, o.Resample, o.GrayModel)
+	}
+	return Resample(img, width, height, o.Resample)
+}
+
+// cropImage returns the part of img inside rect, sharing pixels when the image type allows
+func cropImage(img image.Image, rect image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(out, out.Bounds(), img, rect.Min, draw.Src)
+	return out
+}
 
-	return resizedImg
+// atLeastOne rounds v to the nearest integer, with a minimum of 1
+func atLeastOne(v float64) int {
+	if n := int(v + 0.5); n > 1 {
+		return n
+	}
+	return 1
 }
@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestBoxOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    BoxOptions
+		wantErr bool
+	}{
+		{name: "width only", opts: BoxOptions{Width: 80}},
+		{name: "height only", opts: BoxOptions{Height: 24}},
+		{name: "box", opts: BoxOptions{Width: 80, Height: 24, Fit: FitCover}},
+		{name: "largest box", opts: BoxOptions{Width: MaxBoxSize, Height: MaxBoxSize}},
+		{name: "aspect range", opts: BoxOptions{Width: 80, CharAspect: MinCharAspect}},
+		{name: "no size", opts: BoxOptions{}, wantErr: true},
+		{name: "negative width", opts: BoxOptions{Width: -1, Height: 10}, wantErr: true},
+		{name: "width too large", opts: BoxOptions{Width: MaxBoxSize + 1}, wantErr: true},
+		{name: "height too large", opts: BoxOptions{Width: 80, Height: MaxBoxSize + 1}, wantErr: true},
+		{name: "aspect too small", opts: BoxOptions{Width: 80, CharAspect: 0.05}, wantErr: true},
+		{name: "aspect too large", opts: BoxOptions{Width: 80, CharAspect: 11}, wantErr: true},
+		{name: "negative aspect", opts: BoxOptions{Width: 80, CharAspect: -0.5}, wantErr: true},
+		{name: "NaN aspect", opts: BoxOptions{Width: 80, CharAspect: math.NaN()}, wantErr: true},
+		{name: "infinite aspect", opts: BoxOptions{Width: 80, CharAspect: math.Inf(1)}, wantErr: true},
+		{name: "unknown fit", opts: BoxOptions{Width: 80, Height: 24, Fit: "fill"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestResizeImageToBoxSize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	tests := []struct {
+		name       string
+		opts       BoxOptions
+		wantWidth  int
+		wantHeight int
+	}{
+		{"width only", BoxOptions{Width: 80}, 80, 20},
+		{"height only", BoxOptions{Height: 20}, 80, 20},
+		{"contain", BoxOptions{Width: 80, Height: 30}, 80, 30},
+		{"cover", BoxOptions{Width: 40, Height: 40, Fit: FitCover}, 40, 40},
+		{"stretch", BoxOptions{Width: 10, Height: 50, Fit: FitStretch}, 10, 50},
+		{"aspect", BoxOptions{Width: 80, CharAspect: 1}, 80, 40},
+	}
+	for _, tt := range tests {
+		bounds := ResizeImageToBox(img, tt.opts, 1, 1).Bounds()
+		if bounds.Dx() != tt.wantWidth || bounds.Dy() != tt.wantHeight {
+			t.Errorf("%s: resized to %dx%d, want %dx%d", tt.name, bounds.Dx(), bounds.Dy(), tt.wantWidth, tt.wantHeight)
+		}
+	}
+}
+
+// A very tall image must not derive more than MaxBoxSize rows from a width alone
+func TestConvertTallImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 20000))
+	tests := []struct {
+		mode  string
+		color bool
+	}{
+		{ModeASCII, false},
+		{ModeASCII, true},
+		{ModeBraille, false},
+		{ModeHalfBlock, true},
+		{ModeShape, false},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Width = 200
+		opts.Mode = tt.mode
+		opts.Color = tt.color
+		result, err := Convert(img, opts)
+		if err != nil {
+			t.Errorf("%s: Convert returned error: %v", tt.mode, err)
+			continue
+		}
+		if result.Rows != MaxBoxSize {
+			t.Errorf("%s (color %v): %d rows, want %d", tt.mode, tt.color, result.Rows, MaxBoxSize)
+		}
+	}
+}
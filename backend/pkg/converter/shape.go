@@ -0,0 +1,174 @@
+package converter
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+// shapeGlyph is a palette glyph rasterized for shape matching
+type shapeGlyph struct {
+	char  string
+	tone  float64                                 // Mean coverage
+	dev   [FontCellWidth * FontCellHeight]float64 // Coverage minus its mean (the glyph's structure)
+	sigma float64                                 // Standard deviation of the coverage
+}
+
+// shapeGlyphs rasterizes every glyph of charPalette with the embedded font.
+// Glyphs the font cannot draw are approximated by a uniform fill whose tone
+// follows the glyph's position in the palette, so any ramp can still be matched.
+func shapeGlyphs(charPalette string) []shapeGlyph {
+	runes := []rune(charPalette)
+	glyphs := make([]shapeGlyph, len(runes))
+	var mask [FontCellWidth * FontCellHeight]float64
+	for i, r := range runes {
+		glyphs[i].char = string(r)
+		bitmap, ok := RasterizeGlyph(r)
+		if !ok {
+			tone := 0.0
+			if len(runes) > 1 {
+				tone = float64(i) / float64(len(runes)-1)
+			}
+			for p := range mask {
+				mask[p] = tone
+			}
+		} else {
+			for p, v := range bitmap {
+				mask[p] = float64(v) / 255
+			}
+		}
+
+		mean := 0.0
+		for _, v := range mask {
+			mean += v
+		}
+		mean /= float64(len(mask))
+		variance := 0.0
+		for p, v := range mask {
+			glyphs[i].dev[p] = v - mean
+			variance += (v - mean) * (v - mean)
+		}
+		glyphs[i].tone = mean
+		glyphs[i].sigma = math.Sqrt(variance / float64(len(mask)))
+	}
+
+	return glyphs
+}
+
+// ResizeImageForShape resizes an image so each output character covers one
+// FontCellWidth x FontCellHeight block, the resolution glyphs are matched at
+func ResizeImageForShape(img image.Image, targetWidth int) image.Image {
+	return ResizeImageToCells(img, targetWidth, FontCellWidth, FontCellHeight)
+}
+
+// matchShape returns the glyph that best matches the brightness of the cell at (x0, y0).
+// Mean brightness and structure are scored separately: the squared difference between the
+// block's mean and the glyph's tone, plus the squared difference between the block's
+// mean-subtracted pixels and the glyph's structure scaled to the block's contrast. The block's
+// mean is first stretched onto the palette's range of tones, since even "@" inks well under
+// half its cell and raw coverage would map every mid-tone to the densest glyph. A flat block
+// is then matched on tone alone, while edges pick the glyph whose pattern correlates best.
+// Bright pixels match inked glyph pixels, as the output is drawn light-on-dark.
+// gray is the brightness function.
+func matchShape(img image.Image, x0, y0 int, glyphs []shapeGlyph, gray func(r, g, b uint32) uint8) string {
+	const n = FontCellWidth * FontCellHeight
+	var block [n]float64
+	mean := 0.0
+	for y := 0; y < FontCellHeight; y++ {
+		for x := 0; x < FontCellWidth; x++ {
+			r, g, b, _ := img.At(x0+x, y0+y).RGBA()
+			v := float64(gray(r, g, b)) / 255
+			block[y*FontCellWidth+x] = v
+			mean += v
+		}
+	}
+	mean /= n
+	lo, hi := math.MaxFloat64, 0.0
+	for i := range glyphs {
+		lo, hi = min(lo, glyphs[i].tone), max(hi, glyphs[i].tone)
+	}
+	tone := lo + mean*(hi-lo)
+	variance := 0.0
+	for p := range block {
+		block[p] -= mean
+		variance += block[p] * block[p]
+	}
+	variance /= n
+	sigma := math.Sqrt(variance)
+
+	best := ""
+	bestScore := math.MaxFloat64
+	for i := range glyphs {
+		g := &glyphs[i]
+		// Mean of (block - scale*glyph)^2 over the deviations, with scale = sigma/g.sigma;
+		// a flat glyph has no structure to scale, leaving the block's own variance
+		structure := variance
+		if sigma > 0 && g.sigma > 0 {
+			covariance := 0.0
+			for p, v := range block {
+				covariance += v * g.dev[p]
+			}
+			covariance /= n
+			structure = 2*variance - 2*sigma/g.sigma*covariance
+		}
+		d := tone - g.tone
+		score := d*d + structure
+		if score < bestScore {
+			best, bestScore = g.char, score
+		}
+	}
+	return best
+}
+
+// ConvertToASCIIByShape picks, for each cell, the palette glyph whose rasterized shape best
+// matches the source block rather than its average brightness alone. This gives much crisper
+// results for logos and line drawings. img should come from ResizeImageForShape.
+func ConvertToASCIIByShape(img image.Image, charPalette string) string {
+	return convertToASCIIByShape(img, charPalette, RGBToGrayScale)
+}
+
+// convertToASCIIByShape is ConvertToASCIIByShape with the brightness function gray
+func convertToASCIIByShape(img image.Image, charPalette string, gray func(r, g, b uint32) uint8) string {
+	bounds := img.Bounds()
+	glyphs := shapeGlyphs(charPalette)
+	var builder strings.Builder
+
+	for y := bounds.Min.Y; y+FontCellHeight <= bounds.Max.Y; y += FontCellHeight {
+		for x := bounds.Min.X; x+FontCellWidth <= bounds.Max.X; x += FontCellWidth {
+			builder.WriteString(matchShape(img, x, y, glyphs, gray))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// ConvertToASCIIByShapeColorStructured is the colored counterpart of ConvertToASCIIByShape.
+// Each character's color is the average color of its cell.
+func ConvertToASCIIByShapeColorStructured(img image.Image, charPalette string) ColoredASCII {
+	return convertToASCIIByShapeColorStructured(img, charPalette, RGBToGrayScale)
+}
+
+// convertToASCIIByShapeColorStructured is ConvertToASCIIByShapeColorStructured with the
+// brightness function gray
+func convertToASCIIByShapeColorStructured(img image.Image, charPalette string, gray func(r, g, b uint32) uint8) ColoredASCII {
+	bounds := img.Bounds()
+	glyphs := shapeGlyphs(charPalette)
+	lines := make([][]ColoredChar, 0, bounds.Dy()/FontCellHeight)
+
+	for y := bounds.Min.Y; y+FontCellHeight <= bounds.Max.Y; y += FontCellHeight {
+		line := make([]ColoredChar, 0, bounds.Dx()/FontCellWidth)
+		for x := bounds.Min.X; x+FontCellWidth <= bounds.Max.X; x += FontCellWidth {
+			avg := averageBlockColor(img, image.Rect(x, y, x+FontCellWidth, y+FontCellHeight))
+			line = append(line, ColoredChar{
+				Char: matchShape(img, x, y, glyphs, gray),
+				R:    avg.R,
+				G:    avg.G,
+				B:    avg.B,
+			})
+		}
+		lines = append(lines, line)
+	}
+
+	return ColoredASCII{Lines: lines}
+}
@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// cellImage returns one FontCellWidth x FontCellHeight cell whose brightness at (x, y) is v(x, y)
+func cellImage(v func(x, y int) uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, FontCellWidth, FontCellHeight))
+	for y := 0; y < FontCellHeight; y++ {
+		for x := 0; x < FontCellWidth; x++ {
+			img.SetGray(x, y, color.Gray{Y: v(x, y)})
+		}
+	}
+	return img
+}
+
+func TestMatchShapeFlatTones(t *testing.T) {
+	palette, err := GetPalette(PaletteNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glyphs := shapeGlyphs(palette)
+
+	tones := map[string]float64{}
+	for _, g := range glyphs {
+		tones[g.char] = g.tone
+	}
+
+	// Uniform cells must follow the glyphs' ink coverage, keeping the mid-tones
+	last := -1.0
+	seen := map[float64]bool{}
+	for level := 0; level <= 255; level += 5 {
+		img := cellImage(func(x, y int) uint8 { return uint8(level) })
+		char := matchShape(img, 0, 0, glyphs, RGBToGrayScale)
+		if tones[char] < last {
+			t.Errorf("level %d: got %q, lighter than the glyph for a lower level", level, char)
+		}
+		last = tones[char]
+		seen[tones[char]] = true
+	}
+	distinct := map[float64]bool{}
+	for _, tone := range tones {
+		distinct[tone] = true
+	}
+	if len(seen) != len(distinct) {
+		t.Errorf("uniform levels used %d of %d glyph tones", len(seen), len(distinct))
+	}
+	if first := matchShape(cellImage(func(x, y int) uint8 { return 0 }), 0, 0, glyphs, RGBToGrayScale); first != " " {
+		t.Errorf("black cell: got %q, want \" \"", first)
+	}
+	if full := matchShape(cellImage(func(x, y int) uint8 { return 255 }), 0, 0, glyphs, RGBToGrayScale); full != "@" {
+		t.Errorf("white cell: got %q, want \"@\"", full)
+	}
+}
+
+func TestMatchShapeStructure(t *testing.T) {
+	slash, _ := RasterizeGlyph('/')
+	tests := []struct {
+		name string
+		cell func(x, y int) uint8
+		want string
+	}{
+		{name: "vertical line", cell: func(x, y int) uint8 { return bright(x == FontCellWidth/2) }, want: "|"},
+		{name: "horizontal line", cell: func(x, y int) uint8 { return bright(y == FontCellHeight/2) }, want: "-"},
+		{name: "slash", cell: func(x, y int) uint8 { return slash.At(x, y) }, want: "/"},
+		{name: "flat dark", cell: func(x, y int) uint8 { return 0 }, want: " "},
+		{name: "flat bright", cell: func(x, y int) uint8 { return 255 }, want: "#"},
+	}
+	glyphs := shapeGlyphs(" |-/\\#")
+	for _, tt := range tests {
+		if got := matchShape(cellImage(tt.cell), 0, 0, glyphs, RGBToGrayScale); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// bright returns white for lit pixels and black otherwise
+func bright(lit bool) uint8 {
+	if lit {
+		return 255
+	}
+	return 0
+}
@@ -0,0 +1,228 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// EXIF orientations: how the stored pixels must be transformed to display the image upright
+const (
+	OrientationNormal     = 1 // Already upright
+	OrientationFlipH      = 2 // Mirror left to right
+	OrientationRotate180  = 3 // Rotate 180°
+	OrientationFlipV      = 4 // Mirror top to bottom
+	OrientationTranspose  = 5 // Mirror along the top-left to bottom-right diagonal
+	OrientationRotate90   = 6 // Rotate 90° clockwise
+	OrientationTransverse = 7 // Mirror along the top-right to bottom-left diagonal
+	OrientationRotate270  = 8 // Rotate 270° clockwise (90° counterclockwise)
+)
+
+// CropRect is a region of the image in pixels, or in percent of the image size when Percent
+// is set. The zero value keeps the whole image.
+type CropRect struct {
+	X, Y, Width, Height float64
+	Percent             bool
+}
+
+// ParseCrop parses a crop region written as "x,y,width,height" in pixels, or with every value
+// in percent, e.g. "10%,0%,80%,100%". An empty spec returns the zero CropRect.
+func ParseCrop(spec string) (CropRect, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return CropRect{}, nil
+	}
+
+	parts := strings.Split(spec, ",")
+	if len(parts) != 4 {
+		return CropRect{}, fmt.Errorf("invalid crop %q (expected x,y,width,height)", spec)
+	}
+	var values [4]float64
+	percents := 0
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if strings.HasSuffix(part, "%") {
+			percents++
+			part = strings.TrimSuffix(part, "%")
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return CropRect{}, fmt.Errorf("invalid crop %q (expected x,y,width,height)", spec)
+		}
+		values[i] = v
+	}
+	if percents != 0 && percents != len(parts) {
+		return CropRect{}, fmt.Errorf("invalid crop %q (use pixels or percentages for all four values)", spec)
+	}
+
+	crop := CropRect{X: values[0], Y: values[1], Width: values[2], Height: values[3], Percent: percents > 0}
+	if err := crop.Validate(); err != nil {
+		return CropRect{}, err
+	}
+	return crop, nil
+}
+
+// IsZero reports whether the crop keeps the whole image
+func (c CropRect) IsZero() bool {
+	return c == CropRect{}
+}
+
+// Validate checks that the region has a positive size, a non-negative origin and, in
+// percent, lies within 0-100
+func (c CropRect) Validate() error {
+	if c.IsZero() {
+		return nil
+	}
+	if c.X < 0 || c.Y < 0 || c.Width <= 0 || c.Height <= 0 {
+		return fmt.Errorf("crop needs a non-negative origin and a positive size")
+	}
+	if c.Percent && (c.X+c.Width > 100 || c.Y+c.Height > 100) {
+		return fmt.Errorf("crop percentages must stay within 0-100")
+	}
+	return nil
+}
+
+// Rect returns the pixel rectangle of the crop within bounds, clipped to the image
+func (c CropRect) Rect(bounds image.Rectangle) (image.Rectangle, error) {
+	if c.IsZero() {
+		return bounds, nil
+	}
+	x, y, width, height := c.X, c.Y, c.Width, c.Height
+	if c.Percent {
+		x, width = x*float64(bounds.Dx())/100, width*float64(bounds.Dx())/100
+		y, height = y*float64(bounds.Dy())/100, height*float64(bounds.Dy())/100
+	}
+	min := bounds.Min.Add(image.Pt(int(x+0.5), int(y+0.5)))
+	rect := image.Rectangle{Min: min, Max: min.Add(image.Pt(atLeastOne(width), atLeastOne(height)))}.Intersect(bounds)
+	if rect.Empty() {
+		return image.Rectangle{}, fmt.Errorf("crop lies outside the %dx%d image", bounds.Dx(), bounds.Dy())
+	}
+	return rect, nil
+}
+
+// TransformOptions are geometric transforms applied to the source image before resizing,
+// in order: crop, rotation, then flips
+type TransformOptions struct {
+	Crop   CropRect // Region to keep (zero keeps the whole image)
+	Rotate int      // Clockwise rotation in degrees: 0, 90, 180 or 270
+	FlipH  bool     // Mirror left to right
+	FlipV  bool     // Mirror top to bottom
+}
+
+// Validate checks the crop region and the rotation angle
+func (t TransformOptions) Validate() error {
+	if err := t.Crop.Validate(); err != nil {
+		return err
+	}
+	switch t.Rotate {
+	case 0, 90, 180, 270:
+		return nil
+	default:
+		return fmt.Errorf("invalid rotation %d (valid: 0, 90, 180, 270)", t.Rotate)
+	}
+}
+
+// Apply crops, rotates and flips img. Without transforms the image is returned unchanged.
+func (t TransformOptions) Apply(img image.Image) (image.Image, error) {
+	if !t.Crop.IsZero() {
+		rect, err := t.Crop.Rect(img.Bounds())
+		if err != nil {
+			return nil, err
+		}
+		img = cropImage(img, rect)
+	}
+	switch t.Rotate {
+	case 90:
+		img = Orient(img, OrientationRotate90)
+	case 180:
+		img = Orient(img, OrientationRotate180)
+	case 270:
+		img = Orient(img, OrientationRotate270)
+	}
+	if t.FlipH {
+		img = Orient(img, OrientationFlipH)
+	}
+	if t.FlipV {
+		img = Orient(img, OrientationFlipV)
+	}
+	return img, nil
+}
+
+// Orient transforms img as described by an EXIF orientation (see OrientationNormal ...),
+// so a photo whose camera stored it sideways is returned upright. *image.Gray, *image.RGBA
+// and *image.NRGBA keep their type; other images become *image.RGBA. Orientation 1 and
+// unknown values return img unchanged.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= OrientationNormal || orientation > OrientationRotate270 {
+		return img
+	}
+
+	// Every orientation maps an output pixel (x, y) to a source pixel by optionally swapping
+	// the axes, then optionally mirroring each source axis
+	transpose := orientation >= OrientationTranspose
+	mirrorX := orientation == OrientationFlipH || orientation == OrientationRotate180 ||
+		orientation == OrientationTransverse || orientation == OrientationRotate270
+	mirrorY := orientation == OrientationRotate180 || orientation == OrientationFlipV ||
+		orientation == OrientationRotate90 || orientation == OrientationTransverse
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	outRect := image.Rect(0, 0, width, height)
+	if transpose {
+		outRect = image.Rect(0, 0, height, width)
+	}
+	source := func(x, y int) (int, int) {
+		if transpose {
+			x, y = y, x
+		}
+		if mirrorX {
+			x = width - 1 - x
+		}
+		if mirrorY {
+			y = height - 1 - y
+		}
+		return bounds.Min.X + x, bounds.Min.Y + y
+	}
+
+	// copyPixels moves bytesPerPixel bytes per pixel between images with Pix buffers
+	copyPixels := func(dst, src []byte, dstStride, srcStride, bytesPerPixel int) {
+		parallelRows(0, outRect.Dy(), func(_, y0, y1 int) {
+			for y := y0; y < y1; y++ {
+				for x := 0; x < outRect.Dx(); x++ {
+					sx, sy := source(x, y)
+					si := (sy-bounds.Min.Y)*srcStride + (sx-bounds.Min.X)*bytesPerPixel
+					copy(dst[y*dstStride+x*bytesPerPixel:], src[si:si+bytesPerPixel])
+				}
+			}
+		})
+	}
+
+	switch src := img.(type) {
+	case *image.Gray:
+		out := image.NewGray(outRect)
+		copyPixels(out.Pix, src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y):], out.Stride, src.Stride, 1)
+		return out
+	case *image.RGBA:
+		out := image.NewRGBA(outRect)
+		copyPixels(out.Pix, src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y):], out.Stride, src.Stride, 4)
+		return out
+	case *image.NRGBA:
+		out := image.NewNRGBA(outRect)
+		copyPixels(out.Pix, src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y):], out.Stride, src.Stride, 4)
+		return out
+	}
+
+	out := image.NewRGBA(outRect)
+	pixel := newPixelReader(img)
+	parallelRows(0, outRect.Dy(), func(_, y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := 0; x < outRect.Dx(); x++ {
+				r, g, b, a := pixel(source(x, y))
+				i := out.PixOffset(x, y)
+				out.Pix[i], out.Pix[i+1], out.Pix[i+2], out.Pix[i+3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+			}
+		}
+	})
+	return out
+}
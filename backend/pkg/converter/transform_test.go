@@ -0,0 +1,128 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image numbered row by row:
+	//   1 2 3
+	//   4 5 6
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{OrientationNormal, [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+		{OrientationFlipH, [][]uint8{{3, 2, 1}, {6, 5, 4}}},
+		{OrientationRotate180, [][]uint8{{6, 5, 4}, {3, 2, 1}}},
+		{OrientationFlipV, [][]uint8{{4, 5, 6}, {1, 2, 3}}},
+		{OrientationTranspose, [][]uint8{{1, 4}, {2, 5}, {3, 6}}},
+		{OrientationRotate90, [][]uint8{{4, 1}, {5, 2}, {6, 3}}},
+		{OrientationTransverse, [][]uint8{{6, 3}, {5, 2}, {4, 1}}},
+		{OrientationRotate270, [][]uint8{{3, 6}, {2, 5}, {1, 4}}},
+	}
+
+	// The numbered pixels sit at an offset inside a bigger image, so sub-images are covered too
+	gray := image.NewGray(image.Rect(0, 0, 5, 4))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			gray.SetGray(1+x, 1+y, color.Gray{Y: uint8(1 + y*3 + x)})
+		}
+	}
+	sub := gray.SubImage(image.Rect(1, 1, 4, 3))
+	sources := []struct {
+		name string
+		img  image.Image
+	}{
+		{"gray", sub},
+		{"generic", genericImage{sub}},
+	}
+
+	for _, source := range sources {
+		for _, tt := range tests {
+			got := grayRows(Orient(source.img, tt.orientation))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: Orient(%d) = %v, want %v", source.name, tt.orientation, got, tt.want)
+			}
+		}
+	}
+}
+
+// grayRows returns the gray level of every pixel of img, row by row
+func grayRows(img image.Image) [][]uint8 {
+	bounds := img.Bounds()
+	rows := make([][]uint8, bounds.Dy())
+	for y := range rows {
+		rows[y] = make([]uint8, bounds.Dx())
+		for x := range rows[y] {
+			rows[y][x] = color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y
+		}
+	}
+	return rows
+}
+
+func TestParseCrop(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    CropRect
+		wantErr bool
+	}{
+		{spec: "", want: CropRect{}},
+		{spec: "10,20,300,200", want: CropRect{X: 10, Y: 20, Width: 300, Height: 200}},
+		{spec: " 0, 0 ,1.5,2 ", want: CropRect{Width: 1.5, Height: 2}},
+		{spec: "10%,0%,80%,100%", want: CropRect{X: 10, Width: 80, Height: 100, Percent: true}},
+		{spec: "10,20,300", wantErr: true},
+		{spec: "10,20,300,200,5", wantErr: true},
+		{spec: "a,0,10,10", wantErr: true},
+		{spec: "10%,0,80%,100%", wantErr: true},
+		{spec: "-1,0,10,10", wantErr: true},
+		{spec: "0,0,0,10", wantErr: true},
+		{spec: "50%,0%,60%,100%", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCrop(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseCrop(%q) = %+v, want an error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseCrop(%q) returned error: %v", tt.spec, err)
+		} else if got != tt.want {
+			t.Errorf("ParseCrop(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCropRect(t *testing.T) {
+	bounds := image.Rect(0, 0, 200, 100)
+	tests := []struct {
+		crop    CropRect
+		want    image.Rectangle
+		wantErr bool
+	}{
+		{crop: CropRect{}, want: bounds},
+		{crop: CropRect{X: 10, Y: 20, Width: 50, Height: 30}, want: image.Rect(10, 20, 60, 50)},
+		{crop: CropRect{X: 150, Y: 50, Width: 100, Height: 100}, want: image.Rect(150, 50, 200, 100)},
+		{crop: CropRect{X: 25, Y: 50, Width: 50, Height: 50, Percent: true}, want: image.Rect(50, 50, 150, 100)},
+		{crop: CropRect{X: 300, Y: 0, Width: 10, Height: 10}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.crop.Rect(bounds)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%+v.Rect = %v, want an error", tt.crop, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v.Rect returned error: %v", tt.crop, err)
+		} else if got != tt.want {
+			t.Errorf("%+v.Rect = %v, want %v", tt.crop, got, tt.want)
+		}
+	}
+}
@@ -87,7 +87,7 @@ func ExtractFramesFromVideo(reader io.Reader, targetFps int, originalSize int) (
 			break
 		}
 
-		img, err := LoadImage(framePath)
+		img, _, err := LoadImage(framePath)
 		if err != nil {
 			fmt.Printf("Warning: failed to load frame %d: %v\n", i, err)
 			continue
@@ -174,19 +174,59 @@ func probeVideo(videoPath string) (*VideoMetadata, error) {
 	return metadata, nil
 }
 
-// ProcessVideoToASCII converts all frames to grayscale ASCII
-func ProcessVideoToASCII(frames []image.Image, width int, palette string) ([]FrameASCII, error) {
-	result := make([]FrameASCII, 0, len(frames))
+// ValidateVideoMode reports an error for render modes video frames cannot be converted in.
+// Only ModeASCII is supported (an empty mode means ModeASCII).
+func ValidateVideoMode(mode string) error {
+	if mode != "" && mode != ModeASCII {
+		return fmt.Errorf("mode '%s' is not supported for video (only %s)", mode, ModeASCII)
+	}
+	return nil
+}
 
+// ProcessVideoToASCII converts all frames to grayscale ASCII using the same pipeline as
+// Convert. Frames are rendered in ModeASCII; other modes are rejected (see ValidateVideoMode).
+// Ordered (Bayer) dithering is recommended for video because error diffusion patterns
+// change from frame to frame and shimmer during playback. Global equalization uses one
+// histogram for the whole clip so brightness doesn't pump between frames; CLAHE works per frame.
+func ProcessVideoToASCII(frames []image.Image, opts Options) ([]FrameASCII, error) {
+	if err := ValidateVideoMode(opts.Mode); err != nil {
+		return nil, err
+	}
+	opts.Mode = ModeASCII
+	opts.Color = false
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	charPalette, _ := opts.palette()
+
+	// Prepare and convert every frame to grayscale first so the
+	// clip-wide histogram is available before any frame is mapped
+	resizedFrames := make([]image.Image, len(frames))
+	grayFrames := make([]image.Image, len(frames))
+	var clipHistogram Histogram
 	for i, frame := range frames {
-		// Resize the frame
-		resized := ResizeImage(frame, width)
+		resized, err := opts.prepare(frame)
+		if err != nil {
+			return nil, err
+		}
+		resizedFrames[i] = resized
+		grayFrames[i] = opts.toGray(resizedFrames[i])
+		if opts.Equalize.Method == EqualizeGlobal {
+			clipHistogram.Add(grayFrames[i])
+		}
+	}
+	clipTable := clipHistogram.EqualizationTable()
 
-		// Convert to grayscale
-		grayscale := ConvertToGrayscale(resized)
+	result := make([]FrameASCII, 0, len(frames))
 
-		// Convert to ASCII
-		ascii := ConvertToASCII(grayscale, palette)
+	for i, grayscale := range grayFrames {
+		// Equalize (clip-wide for global), then dither and map to the palette
+		if opts.Equalize.Method == EqualizeGlobal {
+			grayscale = ApplyToneTable(grayscale, clipTable)
+		} else {
+			grayscale = Equalize(grayscale, opts.equalizeOptions())
+		}
+		ascii := opts.mapText(resizedFrames[i], grayscale, charPalette)
 
 		// Calculate timestamp (assuming frames are evenly spaced)
 		timestamp := float64(i) / 10.0 // Default to 10 fps spacing
@@ -201,16 +241,23 @@ func ProcessVideoToASCII(frames []image.Image, width int, palette string) ([]Fra
 	return result, nil
 }
 
-// ProcessVideoToColorASCII converts all frames to colored ASCII
-func ProcessVideoToColorASCII(frames []image.Image, width int, palette string) ([]FrameColorASCII, error) {
+// ProcessVideoToColorASCII converts all frames to colored ASCII with Convert.
+// Frames are rendered in ModeASCII; other modes are rejected (see ValidateVideoMode).
+func ProcessVideoToColorASCII(frames []image.Image, opts Options) ([]FrameColorASCII, error) {
+	if err := ValidateVideoMode(opts.Mode); err != nil {
+		return nil, err
+	}
+	opts.Mode = ModeASCII
+	opts.Color = true
+
 	result := make([]FrameColorASCII, 0, len(frames))
 
 	for i, frame := range frames {
-		// Resize the frame
-		resized := ResizeImage(frame, width)
-
 		// Convert to colored ASCII (structured format)
-		coloredASCII := ConvertToASCIIWithColorStructured(resized, palette)
+		converted, err := Convert(frame, opts)
+		if err != nil {
+			return nil, err
+		}
 
 		// Calculate timestamp (assuming frames are evenly spaced)
 		timestamp := float64(i) / 10.0 // Default to 10 fps spacing
@@ -218,7 +265,7 @@ func ProcessVideoToColorASCII(frames []image.Image, width int, palette string) (
 		result = append(result, FrameColorASCII{
 			Index:     i,
 			Timestamp: timestamp,
-			Lines:     coloredASCII.Lines,
+			Lines:     converted.Colored.Lines,
 		})
 	}
 
@@ -0,0 +1,159 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Video formats ASCII frames can be re-encoded to
+const (
+	VideoMP4  = "mp4"  // H.264 video, AAC audio
+	VideoWebM = "webm" // VP9 video, Opus audio
+)
+
+// VideoEncodeOptions controls how converted video frames are re-encoded into a video file
+type VideoEncodeOptions struct {
+	Raster RasterOptions // Cell size, colors and padding of every frame (Format and Quality are unused)
+	Format string        // VideoMP4 (default) or VideoWebM
+
+	// CRF is the constant rate factor: lower is better quality and bigger files, 0-51 for
+	// H.264 and 0-63 for VP9. 0 picks the codec's default (23 for H.264, 31 for VP9).
+	CRF int
+
+	// Audio is an optional video or audio file, usually the source video, whose audio track
+	// is muxed in and cut to the length of the clip. Sources without audio give a silent video.
+	Audio string
+}
+
+// DefaultVideoEncodeOptions returns frames drawn like DefaultRasterOptions, as H.264 MP4 at
+// the default quality without audio
+func DefaultVideoEncodeOptions() VideoEncodeOptions {
+	return VideoEncodeOptions{Raster: DefaultRasterOptions(), Format: VideoMP4}
+}
+
+// Validate checks the drawing options, the format and the CRF range of its codec
+func (o VideoEncodeOptions) Validate() error {
+	raster := o.Raster
+	raster.Format = RasterPNG
+	if err := raster.Validate(); err != nil {
+		return err
+	}
+	maxCRF := 51
+	switch o.Format {
+	case "", VideoMP4:
+	case VideoWebM:
+		maxCRF = 63
+	default:
+		return fmt.Errorf("unknown video format %q (valid: %s, %s)", o.Format, VideoMP4, VideoWebM)
+	}
+	if o.CRF < 0 || o.CRF > maxCRF {
+		return fmt.Errorf("invalid CRF %d (expected 0-%d)", o.CRF, maxCRF)
+	}
+	return nil
+}
+
+// WriteVideo re-encodes the grayscale frames into a video file at path (see encodeVideo)
+func (v VideoAsciiResult) WriteVideo(path string, opts VideoEncodeOptions) error {
+	frames := make([][][]ColoredChar, len(v.Frames))
+	for i, frame := range v.Frames {
+		frames[i] = rasterLines(frame.ASCII, nil, opts.Raster.Foreground)
+	}
+	return encodeVideo(path, frames, v.Metadata.SampledFps, opts)
+}
+
+// WriteVideo re-encodes the colored frames into a video file at path (see encodeVideo)
+func (v VideoColorAsciiResult) WriteVideo(path string, opts VideoEncodeOptions) error {
+	frames := make([][][]ColoredChar, len(v.Frames))
+	for i, frame := range v.Frames {
+		frames[i] = frame.Lines
+	}
+	return encodeVideo(path, frames, v.Metadata.SampledFps, opts)
+}
+
+// encodeVideo draws every frame with the embedded bitmap font and pipes them to FFmpeg as
+// raw RGBA video at fps. The output is written to a file rather than a stream because MP4
+// needs to seek back to write its index.
+func encodeVideo(path string, frames [][][]ColoredChar, fps int, opts VideoEncodeOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if fps <= 0 {
+		return fmt.Errorf("invalid frame rate %d", fps)
+	}
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+
+	// 4:2:0 chroma subsampling needs even dimensions; the extra pixels are page color
+	bounds, err := rasterBounds(opts.Raster, frames...)
+	if err != nil {
+		return err
+	}
+	bounds.Max.X += bounds.Dx() % 2
+	bounds.Max.Y += bounds.Dy() % 2
+
+	reader, writer := io.Pipe()
+	go func() {
+		// The pipe hands each frame over before returning, so one image is reused for all
+		img := image.NewRGBA(bounds)
+		for _, lines := range frames {
+			drawRasterLines(img, lines, opts.Raster)
+			if _, err := writer.Write(img.Pix); err != nil {
+				return
+			}
+		}
+		writer.Close()
+	}()
+
+	err = encodeVideoCommand(path, bounds, fps, len(frames), opts).WithInput(reader).Run()
+	// Unblock the frame writer if FFmpeg stopped reading early
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to encode video: %w", err)
+	}
+	return nil
+}
+
+// encodeVideoCommand builds the FFmpeg command reading frameCount raw frames of size bounds
+// from stdin
+func encodeVideoCommand(path string, bounds image.Rectangle, fps, frameCount int, opts VideoEncodeOptions) *ffmpeg.Stream {
+	streams := []*ffmpeg.Stream{ffmpeg.Input("pipe:", ffmpeg.KwArgs{
+		"format":     "rawvideo",
+		"pix_fmt":    "rgba",
+		"video_size": fmt.Sprintf("%dx%d", bounds.Dx(), bounds.Dy()),
+		"framerate":  fps,
+	})}
+	if opts.Audio != "" {
+		// "a?" maps the first audio track if there is one
+		duration := strconv.FormatFloat(float64(frameCount)/float64(fps), 'f', 3, 64)
+		streams = append(streams, ffmpeg.Input(opts.Audio, ffmpeg.KwArgs{"t": duration}).Get("a?"))
+	}
+
+	args := ffmpeg.KwArgs{"pix_fmt": "yuv420p"}
+	switch opts.Format {
+	case VideoWebM:
+		args["format"] = "webm"
+		args["c:v"] = "libvpx-vp9"
+		args["crf"] = 31
+		// A zero bitrate makes the CRF the only quality target
+		args["b:v"] = "0"
+		args["row-mt"] = 1
+		args["c:a"] = "libopus"
+	default:
+		args["format"] = "mp4"
+		args["c:v"] = "libx264"
+		args["crf"] = 23
+		// Put the index first so browsers can start playing before the download finishes
+		args["movflags"] = "+faststart"
+		args["c:a"] = "aac"
+	}
+	if opts.CRF > 0 {
+		args["crf"] = opts.CRF
+	}
+
+	return ffmpeg.Output(streams, path, args).OverWriteOutput().ErrorToStdOut()
+}
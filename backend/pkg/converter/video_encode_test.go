@@ -0,0 +1,130 @@
+package converter
+
+import (
+	"errors"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestVideoEncodeOptionsValidate(t *testing.T) {
+	defaults := DefaultVideoEncodeOptions()
+	with := func(modify func(o *VideoEncodeOptions)) VideoEncodeOptions {
+		opts := defaults
+		modify(&opts)
+		return opts
+	}
+	tests := []struct {
+		name    string
+		opts    VideoEncodeOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: defaults},
+		{name: "empty format", opts: with(func(o *VideoEncodeOptions) { o.Format = "" })},
+		{name: "mp4 largest CRF", opts: with(func(o *VideoEncodeOptions) { o.CRF = 51 })},
+		{name: "webm largest CRF", opts: with(func(o *VideoEncodeOptions) { o.Format, o.CRF = VideoWebM, 63 })},
+		{name: "raster format ignored", opts: with(func(o *VideoEncodeOptions) { o.Raster.Format, o.Raster.Quality = RasterJPEG, 0 })},
+		{name: "mp4 CRF too large", opts: with(func(o *VideoEncodeOptions) { o.CRF = 52 }), wantErr: true},
+		{name: "webm CRF too large", opts: with(func(o *VideoEncodeOptions) { o.Format, o.CRF = VideoWebM, 64 }), wantErr: true},
+		{name: "negative CRF", opts: with(func(o *VideoEncodeOptions) { o.CRF = -1 }), wantErr: true},
+		{name: "unknown format", opts: with(func(o *VideoEncodeOptions) { o.Format = "avi" }), wantErr: true},
+		{name: "bad cell size", opts: with(func(o *VideoEncodeOptions) { o.Raster.CellWidth = 0 }), wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// These errors are all returned before FFmpeg is started
+func TestEncodeVideoErrors(t *testing.T) {
+	frame := rasterLines("ab\ncd", nil, RGB{255, 255, 255})
+	// 10000x100 cells at 7x13 pixels is over 90 million pixels
+	huge := make([][]ColoredChar, 100)
+	for i := range huge {
+		huge[i] = make([]ColoredChar, 10000)
+	}
+	tests := []struct {
+		name   string
+		frames [][][]ColoredChar
+		fps    int
+		opts   VideoEncodeOptions
+		target error
+	}{
+		{name: "invalid options", frames: [][][]ColoredChar{frame}, fps: 10, opts: VideoEncodeOptions{}},
+		{name: "zero frame rate", frames: [][][]ColoredChar{frame}, fps: 0, opts: DefaultVideoEncodeOptions()},
+		{name: "no frames", fps: 10, opts: DefaultVideoEncodeOptions()},
+		{name: "frame too large", frames: [][][]ColoredChar{frame, huge}, fps: 10, opts: DefaultVideoEncodeOptions(), target: ErrRasterTooLarge},
+	}
+	for _, tt := range tests {
+		err := encodeVideo(t.TempDir()+"/out.mp4", tt.frames, tt.fps, tt.opts)
+		if err == nil {
+			t.Errorf("%s: encodeVideo returned no error", tt.name)
+			continue
+		}
+		if tt.target != nil && !errors.Is(err, tt.target) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.target)
+		}
+	}
+}
+
+// hasArg reports whether args contains flag directly followed by value
+func hasArg(args []string, flag, value string) bool {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == flag && args[i+1] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEncodeVideoCommand(t *testing.T) {
+	bounds := image.Rect(0, 0, 64, 48)
+	tests := []struct {
+		name    string
+		opts    VideoEncodeOptions
+		want    [][2]string
+		notWant []string
+	}{
+		{
+			name: "mp4",
+			opts: VideoEncodeOptions{Format: VideoMP4},
+			want: [][2]string{
+				{"-video_size", "64x48"}, {"-framerate", "12"}, {"-c:v", "libx264"}, {"-crf", "23"},
+				{"-movflags", "+faststart"}, {"-pix_fmt", "yuv420p"}, {"-f", "mp4"},
+			},
+			notWant: []string{"-t"},
+		},
+		{
+			name: "webm",
+			opts: VideoEncodeOptions{Format: VideoWebM},
+			want: [][2]string{{"-c:v", "libvpx-vp9"}, {"-crf", "31"}, {"-b:v", "0"}, {"-f", "webm"}},
+		},
+		{
+			name: "CRF override",
+			opts: VideoEncodeOptions{Format: VideoWebM, CRF: 40},
+			want: [][2]string{{"-crf", "40"}},
+		},
+		{
+			name: "audio",
+			opts: VideoEncodeOptions{Audio: "source.mp4"},
+			want: [][2]string{{"-t", "2.500"}, {"-i", "source.mp4"}, {"-c:a", "aac"}, {"-map", "1:a?"}},
+		},
+	}
+	for _, tt := range tests {
+		args := encodeVideoCommand("out", bounds, 12, 30, tt.opts).GetArgs()
+		for _, pair := range tt.want {
+			if !hasArg(args, pair[0], pair[1]) {
+				t.Errorf("%s: args %q lack %s %s", tt.name, strings.Join(args, " "), pair[0], pair[1])
+			}
+		}
+		for _, flag := range tt.notWant {
+			for _, arg := range args {
+				if arg == flag {
+					t.Errorf("%s: args %q contain %s", tt.name, strings.Join(args, " "), flag)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxGIFPixels limits the pixels stored across all frames of a GIF, which are held in memory
+// (one byte per pixel) until the whole animation is encoded
+var maxGIFPixels = 200_000_000
+
+// GIFOptions controls how converted video frames are exported as an animated GIF
+type GIFOptions struct {
+	Raster RasterOptions // Cell size, colors and padding of every frame (Format and Quality are unused)
+	Colors int           // Size of the palette shared by all frames, 2-256
+}
+
+// DefaultGIFOptions returns frames drawn like DefaultRasterOptions with a 256-color palette
+func DefaultGIFOptions() GIFOptions {
+	return GIFOptions{Raster: DefaultRasterOptions(), Colors: 256}
+}
+
+// Validate checks the drawing options and the palette size
+func (o GIFOptions) Validate() error {
+	raster := o.Raster
+	raster.Format = RasterPNG
+	if err := raster.Validate(); err != nil {
+		return err
+	}
+	if o.Colors < 2 || o.Colors > 256 {
+		return fmt.Errorf("invalid GIF palette size %d (expected 2-256)", o.Colors)
+	}
+	return nil
+}
+
+// WriteGIF encodes the grayscale frames as an animated GIF (see writeVideoGIF)
+func (v VideoAsciiResult) WriteGIF(w io.Writer, opts GIFOptions) error {
+	frames := make([][][]ColoredChar, len(v.Frames))
+	for i, frame := range v.Frames {
+		frames[i] = rasterLines(frame.ASCII, nil, opts.Raster.Foreground)
+	}
+	return writeVideoGIF(w, frames, v.Metadata.SampledFps, opts)
+}
+
+// WriteGIF encodes the colored frames as an animated GIF (see writeVideoGIF)
+func (v VideoColorAsciiResult) WriteGIF(w io.Writer, opts GIFOptions) error {
+	frames := make([][][]ColoredChar, len(v.Frames))
+	for i, frame := range v.Frames {
+		frames[i] = frame.Lines
+	}
+	return writeVideoGIF(w, frames, v.Metadata.SampledFps, opts)
+}
+
+// writeVideoGIF draws every frame with the embedded bitmap font and encodes the clip as a
+// looping GIF played at fps. All frames share one palette: the exact cell colors when they
+// fit, otherwise a median cut of them weighted by how often each is used. After the first
+// frame only the rectangle that changed is stored, copied out of two full-size buffers that
+// take turns holding the previous frame, and unchanged frames extend the delay of the one
+// before them. Clips that would store more than maxGIFPixels in total return ErrRasterTooLarge.
+func writeVideoGIF(w io.Writer, frames [][][]ColoredChar, fps int, opts GIFOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if fps <= 0 {
+		return fmt.Errorf("invalid frame rate %d", fps)
+	}
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+
+	raster := opts.Raster
+	palette, index := gifPalette(frames, raster.Background, opts.Colors)
+
+	bounds, err := rasterBounds(raster, frames...)
+	if err != nil {
+		return err
+	}
+
+	anim := &gif.GIF{Config: image.Config{ColorModel: palette, Width: bounds.Dx(), Height: bounds.Dy()}}
+	current := image.NewPaletted(bounds, palette)
+	var previous *image.Paletted
+	stored := 0
+	for i, lines := range frames {
+		// Delays are in hundredths of a second; rounding the running total keeps the clip in sync
+		delay := int(math.Round(float64(i+1)*100/float64(fps))) - int(math.Round(float64(i)*100/float64(fps)))
+
+		drawPalettedFrame(current, lines, index, raster)
+		changed := bounds
+		if previous != nil {
+			changed = changedRect(previous, current)
+			if changed.Empty() {
+				anim.Delay[len(anim.Delay)-1] += delay
+				continue
+			}
+		} else {
+			previous = image.NewPaletted(bounds, palette)
+		}
+		if stored += changed.Dx() * changed.Dy(); stored > maxGIFPixels {
+			return fmt.Errorf("%w: the GIF frames add up to more than %d million pixels (use a smaller width or cell size, or fewer frames)",
+				ErrRasterTooLarge, maxGIFPixels/1_000_000)
+		}
+		anim.Image = append(anim.Image, copyPaletted(current, changed))
+		anim.Delay = append(anim.Delay, delay)
+		previous, current = current, previous
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// gifColor is a color and how many cells use it
+type gifColor struct {
+	color RGB
+	count int
+}
+
+// gifPalette returns the palette for frames and the palette index of every color they use.
+// background is the page color shown around and behind the cells.
+func gifPalette(frames [][][]ColoredChar, background RGB, size int) (color.Palette, map[RGB]uint8) {
+	counts := map[RGB]int{background: 1}
+	for _, lines := range frames {
+		for _, line := range lines {
+			for _, char := range line {
+				if char.Transparent {
+					continue
+				}
+				counts[RGB{R: char.R, G: char.G, B: char.B}]++
+				if char.Background != nil {
+					counts[*char.Background]++
+				}
+			}
+		}
+	}
+	colors := make([]gifColor, 0, len(counts))
+	for c, n := range counts {
+		colors = append(colors, gifColor{color: c, count: n})
+	}
+	// Most used first, so the encoding does not depend on map order
+	sort.Slice(colors, func(i, j int) bool {
+		a, b := colors[i], colors[j]
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return uint32(a.color.R)<<16|uint32(a.color.G)<<8|uint32(a.color.B) < uint32(b.color.R)<<16|uint32(b.color.G)<<8|uint32(b.color.B)
+	})
+
+	var entries []RGB
+	if len(colors) <= size {
+		for _, c := range colors {
+			entries = append(entries, c.color)
+		}
+	} else {
+		entries = medianCut(colors, size)
+	}
+
+	palette := make(color.Palette, len(entries))
+	for i, c := range entries {
+		palette[i] = color.RGBA{R: c.R, G: c.G, B: c.B, A: 255}
+	}
+	index := make(map[RGB]uint8, len(colors))
+	for _, c := range colors {
+		index[c.color] = uint8(nearestEntry(entries, c.color))
+	}
+	return palette, index
+}
+
+// medianCut reduces colors to at most size representatives: the box of colors with the
+// widest channel range is split at its weighted median until there are size boxes, and each
+// box becomes its weighted mean color
+func medianCut(colors []gifColor, size int) []RGB {
+	channel := func(c RGB, ch int) int {
+		switch ch {
+		case 0:
+			return int(c.R)
+		case 1:
+			return int(c.G)
+		default:
+			return int(c.B)
+		}
+	}
+	// widest returns the channel with the largest range in box, and that range
+	widest := func(box []gifColor) (int, int) {
+		bestChannel, bestRange := 0, -1
+		for ch := 0; ch < 3; ch++ {
+			lo, hi := 255, 0
+			for _, c := range box {
+				v := channel(c.color, ch)
+				lo, hi = min(lo, v), max(hi, v)
+			}
+			if hi-lo > bestRange {
+				bestChannel, bestRange = ch, hi-lo
+			}
+		}
+		return bestChannel, bestRange
+	}
+
+	boxes := [][]gifColor{colors}
+	for len(boxes) < size {
+		split, splitChannel, splitRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if ch, r := widest(box); r > splitRange {
+				split, splitChannel, splitRange = i, ch, r
+			}
+		}
+		if split < 0 {
+			break
+		}
+
+		box := boxes[split]
+		sort.Slice(box, func(a, b int) bool {
+			return channel(box[a].color, splitChannel) < channel(box[b].color, splitChannel)
+		})
+		total := 0
+		for _, c := range box {
+			total += c.count
+		}
+		cut, sum := 0, 0
+		for cut < len(box)-1 {
+			sum += box[cut].count
+			cut++
+			if 2*sum >= total {
+				break
+			}
+		}
+		boxes[split] = box[:cut]
+		boxes = append(boxes, box[cut:])
+	}
+
+	entries := make([]RGB, len(boxes))
+	for i, box := range boxes {
+		var r, g, b, n int
+		for _, c := range box {
+			r += int(c.color.R) * c.count
+			g += int(c.color.G) * c.count
+			b += int(c.color.B) * c.count
+			n += c.count
+		}
+		entries[i] = RGB{R: uint8((r + n/2) / n), G: uint8((g + n/2) / n), B: uint8((b + n/2) / n)}
+	}
+	return entries
+}
+
+// nearestEntry returns the index of the palette entry closest to c
+func nearestEntry(entries []RGB, c RGB) int {
+	best, bestDistance := 0, math.MaxInt
+	for i, e := range entries {
+		dr, dg, db := int(e.R)-int(c.R), int(e.G)-int(c.G), int(e.B)-int(c.B)
+		if d := dr*dr + dg*dg + db*db; d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+// drawPalettedFrame fills img with the page color and draws one frame like RasterizeASCII,
+// directly in palette indices. The embedded font has no partial coverage at its own cell
+// size, so a pixel is inked when its coverage is at least half.
+func drawPalettedFrame(img *image.Paletted, lines [][]ColoredChar, index map[RGB]uint8, opts RasterOptions) {
+	page := index[opts.Background]
+	for i := range img.Pix {
+		img.Pix[i] = page
+	}
+
+	parallelRows(0, len(lines), func(_, row0, row1 int) {
+		for row := row0; row < row1; row++ {
+			for column, char := range lines[row] {
+				if char.Transparent {
+					continue
+				}
+				x0 := opts.Padding + column*opts.CellWidth
+				y0 := opts.Padding + row*opts.CellHeight
+				ink := index[RGB{R: char.R, G: char.G, B: char.B}]
+				paper := page
+				if char.Background != nil {
+					paper = index[*char.Background]
+				}
+				r, _ := utf8.DecodeRuneInString(char.Char)
+				bitmap, _ := RasterizeGlyph(r)
+
+				for y := 0; y < opts.CellHeight; y++ {
+					offset := img.PixOffset(x0, y0+y)
+					fy := y * FontCellHeight / opts.CellHeight
+					for x := 0; x < opts.CellWidth; x++ {
+						if bitmap.At(x*FontCellWidth/opts.CellWidth, fy) >= 128 {
+							img.Pix[offset+x] = ink
+						} else {
+							img.Pix[offset+x] = paper
+						}
+					}
+				}
+			}
+		}
+	})
+}
+
+// copyPaletted copies the part of img inside rect into a new image of just that size, so
+// the stored frame does not keep the full-size buffer alive
+func copyPaletted(img *image.Paletted, rect image.Rectangle) *image.Paletted {
+	out := image.NewPaletted(rect, img.Palette)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		copy(out.Pix[out.PixOffset(rect.Min.X, y):], img.Pix[img.PixOffset(rect.Min.X, y):img.PixOffset(rect.Max.X, y)])
+	}
+	return out
+}
+
+// changedRect returns the smallest rectangle containing every pixel that differs between
+// two frames of the same size
+func changedRect(previous, current *image.Paletted) image.Rectangle {
+	bounds := current.Bounds()
+	changed := image.Rectangle{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := current.PixOffset(bounds.Min.X, y)
+		row, previousRow := current.Pix[offset:offset+bounds.Dx()], previous.Pix[offset:offset+bounds.Dx()]
+		first := -1
+		for x := range row {
+			if row[x] != previousRow[x] {
+				first = x
+				break
+			}
+		}
+		if first < 0 {
+			continue
+		}
+		last := first
+		for x := len(row) - 1; x > first; x-- {
+			if row[x] != previousRow[x] {
+				last = x
+				break
+			}
+		}
+		changed = changed.Union(image.Rect(bounds.Min.X+first, y, bounds.Min.X+last+1, y+1))
+	}
+	return changed
+}
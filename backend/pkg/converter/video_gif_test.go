@@ -0,0 +1,141 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"reflect"
+	"testing"
+)
+
+func TestWriteVideoGIF(t *testing.T) {
+	white := RGB{R: 255, G: 255, B: 255}
+	a := rasterLines("ab\ncd", nil, white)
+	b := rasterLines("ab\nxd", nil, white)
+	opts := DefaultGIFOptions()
+
+	tests := []struct {
+		name       string
+		frames     [][][]ColoredChar
+		fps        int
+		wantDelays []int
+	}{
+		{"every frame changes", [][][]ColoredChar{a, b, a}, 10, []int{10, 10, 10}},
+		{"delays keep in sync", [][][]ColoredChar{a, b, a, b}, 15, []int{7, 6, 7, 7}},
+		{"unchanged frames merge", [][][]ColoredChar{a, a, b, b, b, a}, 15, []int{13, 20, 7}},
+		{"one frame", [][][]ColoredChar{a}, 3, []int{33}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeVideoGIF(&buf, tt.frames, tt.fps, opts); err != nil {
+			t.Errorf("%s: writeVideoGIF returned error: %v", tt.name, err)
+			continue
+		}
+		anim, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Errorf("%s: invalid GIF: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(anim.Delay, tt.wantDelays) {
+			t.Errorf("%s: delays = %v, want %v", tt.name, anim.Delay, tt.wantDelays)
+		}
+		// 2x2 cells of 7x13 pixels with 7 pixels of padding
+		full := image.Rect(0, 0, 28, 40)
+		if anim.Config.Width != full.Dx() || anim.Config.Height != full.Dy() {
+			t.Errorf("%s: size %dx%d, want %dx%d", tt.name, anim.Config.Width, anim.Config.Height, full.Dx(), full.Dy())
+		}
+		if anim.Image[0].Bounds() != full {
+			t.Errorf("%s: first frame bounds %v, want %v", tt.name, anim.Image[0].Bounds(), full)
+		}
+		// Only the changed cell, the first one of the second row, is stored after the first frame
+		cell := image.Rect(7, 20, 14, 33)
+		for i, frame := range anim.Image[1:] {
+			if !frame.Bounds().In(cell) {
+				t.Errorf("%s: frame %d bounds %v, want within %v", tt.name, i+1, frame.Bounds(), cell)
+			}
+		}
+	}
+}
+
+func TestWriteVideoGIFErrors(t *testing.T) {
+	lines := rasterLines("ab\ncd", nil, RGB{R: 255, G: 255, B: 255})
+	tooManyColors := DefaultGIFOptions()
+	tooManyColors.Colors = 257
+	tooFewColors := DefaultGIFOptions()
+	tooFewColors.Colors = 1
+	padded := DefaultGIFOptions()
+	padded.Raster.Padding = maxRasterScale*FontCellWidth + 1
+
+	tests := []struct {
+		name   string
+		frames [][][]ColoredChar
+		fps    int
+		opts   GIFOptions
+	}{
+		{"no frames", nil, 10, DefaultGIFOptions()},
+		{"zero fps", [][][]ColoredChar{lines}, 0, DefaultGIFOptions()},
+		{"too many colors", [][][]ColoredChar{lines}, 10, tooManyColors},
+		{"too few colors", [][][]ColoredChar{lines}, 10, tooFewColors},
+		{"padding too large", [][][]ColoredChar{lines}, 10, padded},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeVideoGIF(&buf, tt.frames, tt.fps, tt.opts); err == nil {
+			t.Errorf("%s: writeVideoGIF succeeded, want an error", tt.name)
+		}
+	}
+}
+
+func TestWriteVideoGIFPixelBudget(t *testing.T) {
+	defer func(limit int) { maxGIFPixels = limit }(maxGIFPixels)
+	white := RGB{R: 255, G: 255, B: 255}
+	a := rasterLines("ab\ncd", nil, white)
+	b := rasterLines("ba\ndc", nil, white)
+
+	// Each frame is 28x40 pixels, and every other frame changes the 14x26 pixel art
+	maxGIFPixels = 28*40 + 2*14*26
+	var buf bytes.Buffer
+	if err := writeVideoGIF(&buf, [][][]ColoredChar{a, b, a}, 10, DefaultGIFOptions()); err != nil {
+		t.Errorf("writeVideoGIF within the budget returned error: %v", err)
+	}
+	err := writeVideoGIF(&buf, [][][]ColoredChar{a, b, a, b}, 10, DefaultGIFOptions())
+	if !errors.Is(err, ErrRasterTooLarge) {
+		t.Errorf("writeVideoGIF over the budget error = %v, want ErrRasterTooLarge", err)
+	}
+}
+
+func TestGIFPalette(t *testing.T) {
+	var lines [][]ColoredChar
+	for r := 0; r < 32; r++ {
+		var line []ColoredChar
+		for g := 0; g < 32; g++ {
+			line = append(line, ColoredChar{Char: "#", R: uint8(r * 8), G: uint8(g * 8), B: 128})
+		}
+		lines = append(lines, line)
+	}
+	tests := []struct {
+		size, want int
+	}{
+		{256, 256},
+		{16, 16},
+		{2, 2},
+	}
+	for _, tt := range tests {
+		palette, index := gifPalette([][][]ColoredChar{lines}, RGB{}, tt.size)
+		if len(palette) != tt.want {
+			t.Errorf("gifPalette(%d) has %d colors, want %d", tt.size, len(palette), tt.want)
+		}
+		// Every color used, and the page color, maps to an entry
+		if len(index) != 32*32+1 {
+			t.Errorf("gifPalette(%d) indexes %d colors, want %d", tt.size, len(index), 32*32+1)
+		}
+	}
+
+	// Colors that fit are kept exactly, most used first
+	few := [][]ColoredChar{{{Char: "a", R: 255}, {Char: "b", R: 255}, {Char: "c", G: 255}}}
+	palette, index := gifPalette([][][]ColoredChar{few}, RGB{}, 256)
+	if len(palette) != 3 || index[RGB{R: 255}] != 0 {
+		t.Errorf("gifPalette = %v with red at %d, want 3 colors with red first", palette, index[RGB{R: 255}])
+	}
+}
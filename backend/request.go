@@ -0,0 +1,490 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/brandonnguyenn27/ascii-converter/pkg/converter"
+	"github.com/gofiber/fiber/v2"
+)
+
+// optionsFromRequest reads the "width", "height", "charAspect", "fit", "resample", "fastGray", "grayModel", "palette", "paletteChars", "mode", "threshold",
+// "dots", "edgeOperator", "edgeThreshold" and "dither" fields (or query params), plus the
+// geometric transform, tone adjustment, automatic contrast, spatial filter and transparency settings.
+// Color output is left to the handler; call Validate once it is set.
+func optionsFromRequest(c *fiber.Ctx, defaultDither string) (converter.Options, error) {
+	opts := converter.DefaultOptions()
+	opts.Palette = formOrQuery(c, "palette")
+	opts.PaletteChars = formOrQuery(c, "paletteChars")
+	if mode := formOrQuery(c, "mode"); mode != "" {
+		opts.Mode = mode
+	}
+
+	// Width and height must be positive integers (default: 100 wide, or
+	// derived from the height when only a height is given)
+	widthStr := formOrQuery(c, "width")
+	if widthStr != "" {
+		if parsedWidth, err := strconv.Atoi(widthStr); err == nil && parsedWidth > 0 {
+			opts.Width = parsedWidth
+		}
+	}
+	if heightStr := formOrQuery(c, "height"); heightStr != "" {
+		if parsedHeight, err := strconv.Atoi(heightStr); err == nil && parsedHeight > 0 {
+			opts.Height = parsedHeight
+			if widthStr == "" {
+				opts.Width = 0
+			}
+		}
+	}
+	if aspectStr := formOrQuery(c, "charAspect"); aspectStr != "" {
+		parsed, err := strconv.ParseFloat(aspectStr, 64)
+		if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) || parsed <= 0 {
+			return converter.Options{}, fmt.Errorf("invalid charAspect %q", aspectStr)
+		}
+		opts.CharAspect = parsed
+	}
+	opts.Fit = formOrQuery(c, "fit")
+	if resample := formOrQuery(c, "resample"); resample != "" {
+		opts.Resample = resample
+	}
+	opts.FastGray = formOrQuery(c, "fastGray") == "true"
+	if grayModel := formOrQuery(c, "grayModel"); grayModel != "" {
+		opts.GrayModel = grayModel
+	}
+
+	dither, err := ditherFromRequest(c, defaultDither)
+	if err != nil {
+		return converter.Options{}, err
+	}
+	opts.Dither = dither
+
+	if thresholdStr := formOrQuery(c, "threshold"); thresholdStr != "" {
+		parsed, err := strconv.Atoi(thresholdStr)
+		if err != nil {
+			return converter.Options{}, fmt.Errorf("invalid threshold %q", thresholdStr)
+		}
+		opts.Braille.Threshold = clampUint8(parsed)
+	}
+	if dots := formOrQuery(c, "dots"); dots != "" {
+		opts.Braille.Dots = dots
+	}
+	if operator := formOrQuery(c, "edgeOperator"); operator != "" {
+		opts.Edge.Operator = operator
+	}
+	if edgeThresholdStr := formOrQuery(c, "edgeThreshold"); edgeThresholdStr != "" {
+		parsed, err := strconv.ParseFloat(edgeThresholdStr, 64)
+		if err != nil {
+			return converter.Options{}, fmt.Errorf("invalid edgeThreshold %q", edgeThresholdStr)
+		}
+		opts.Edge.Threshold = parsed
+	}
+
+	if opts.Transform, err = transformFromRequest(c); err != nil {
+		return converter.Options{}, err
+	}
+	if opts.Adjust, err = adjustmentsFromRequest(c); err != nil {
+		return converter.Options{}, err
+	}
+	if opts.Equalize, err = equalizeFromRequest(c); err != nil {
+		return converter.Options{}, err
+	}
+	if opts.Filters, err = filtersFromRequest(c); err != nil {
+		return converter.Options{}, err
+	}
+	if opts.Alpha, err = alphaFromRequest(c); err != nil {
+		return converter.Options{}, err
+	}
+	return opts, nil
+}
+
+// ditherFromRequest reads the "dither" field (or query param), using defaultMethod when absent
+func ditherFromRequest(c *fiber.Ctx, defaultMethod string) (string, error) {
+	dither := formOrQuery(c, "dither")
+	if dither == "" {
+		dither = defaultMethod
+	}
+	if err := converter.ValidateDither(dither); err != nil {
+		return "", err
+	}
+	return dither, nil
+}
+
+// frameFromRequest reads the "frame" field (or query param): the animated GIF frame to convert
+// (default: 0, the first frame)
+func frameFromRequest(c *fiber.Ctx) (int, error) {
+	frameStr := formOrQuery(c, "frame")
+	if frameStr == "" {
+		return 0, nil
+	}
+	frame, err := strconv.Atoi(frameStr)
+	if err != nil || frame < 0 {
+		return 0, fmt.Errorf("invalid frame %q (expected a frame number counted from 0)", frameStr)
+	}
+	return frame, nil
+}
+
+// htmlFromRequest reads the "fontFamily", "fontSize", "lineHeight", "background",
+// "foreground" and "fragment" fields (or query params) of an HTML export
+func htmlFromRequest(c *fiber.Ctx) (converter.HTMLOptions, error) {
+	opts := converter.DefaultHTMLOptions()
+	if fontFamily := formOrQuery(c, "fontFamily"); fontFamily != "" {
+		opts.FontFamily = fontFamily
+	}
+	if fontSizeStr := formOrQuery(c, "fontSize"); fontSizeStr != "" {
+		parsed, err := strconv.Atoi(fontSizeStr)
+		if err != nil {
+			return converter.HTMLOptions{}, fmt.Errorf("invalid fontSize %q", fontSizeStr)
+		}
+		opts.FontSize = parsed
+	}
+	if lineHeightStr := formOrQuery(c, "lineHeight"); lineHeightStr != "" {
+		parsed, err := strconv.ParseFloat(lineHeightStr, 64)
+		if err != nil {
+			return converter.HTMLOptions{}, fmt.Errorf("invalid lineHeight %q", lineHeightStr)
+		}
+		opts.LineHeight = parsed
+	}
+	colors := []struct {
+		key    string
+		target *converter.RGB
+	}{
+		{"background", &opts.Background},
+		{"foreground", &opts.Foreground},
+	}
+	for _, col := range colors {
+		if value := formOrQuery(c, col.key); value != "" {
+			parsed, err := converter.ParseHexColor(value)
+			if err != nil {
+				return converter.HTMLOptions{}, err
+			}
+			*col.target = parsed
+		}
+	}
+	opts.Fragment = formOrQuery(c, "fragment") == "true"
+
+	if err := opts.Validate(); err != nil {
+		return converter.HTMLOptions{}, err
+	}
+	return opts, nil
+}
+
+// svgFromRequest reads the "fontFamily", "fontSize", "charWidth", "lineHeight", "letterSpacing",
+// "background" (#rrggbb or "transparent") and "foreground" fields (or query params) of an SVG
+// export, and the optional "font" file to embed
+func svgFromRequest(c *fiber.Ctx) (converter.SVGOptions, error) {
+	opts := converter.DefaultSVGOptions()
+	if fontFamily := formOrQuery(c, "fontFamily"); fontFamily != "" {
+		opts.FontFamily = fontFamily
+	}
+	if fontSizeStr := formOrQuery(c, "fontSize"); fontSizeStr != "" {
+		parsed, err := strconv.Atoi(fontSizeStr)
+		if err != nil {
+			return converter.SVGOptions{}, fmt.Errorf("invalid fontSize %q", fontSizeStr)
+		}
+		opts.FontSize = parsed
+	}
+	floats := []struct {
+		key    string
+		target *float64
+	}{
+		{"charWidth", &opts.CharWidth},
+		{"lineHeight", &opts.LineHeight},
+		{"letterSpacing", &opts.LetterSpacing},
+	}
+	for _, f := range floats {
+		if value := formOrQuery(c, f.key); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return converter.SVGOptions{}, fmt.Errorf("invalid %s %q", f.key, value)
+			}
+			*f.target = parsed
+		}
+	}
+	if bg := formOrQuery(c, "background"); bg == "transparent" {
+		opts.Background = nil
+	} else if bg != "" {
+		parsed, err := converter.ParseHexColor(bg)
+		if err != nil {
+			return converter.SVGOptions{}, err
+		}
+		opts.Background = &parsed
+	}
+	if fg := formOrQuery(c, "foreground"); fg != "" {
+		parsed, err := converter.ParseHexColor(fg)
+		if err != nil {
+			return converter.SVGOptions{}, err
+		}
+		opts.Foreground = parsed
+	}
+
+	if fontFile, err := c.FormFile("font"); err == nil {
+		file, err := fontFile.Open()
+		if err != nil {
+			return converter.SVGOptions{}, fmt.Errorf("failed to open font file")
+		}
+		defer file.Close()
+		if opts.Font, err = io.ReadAll(file); err != nil {
+			return converter.SVGOptions{}, fmt.Errorf("failed to read font file")
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return converter.SVGOptions{}, err
+	}
+	return opts, nil
+}
+
+// gifFromRequest reads the drawing fields of rasterFromRequest and the optional "gifColors"
+// palette size (2-256) of an animated GIF export
+func gifFromRequest(c *fiber.Ctx) (converter.GIFOptions, error) {
+	raster, err := rasterFromRequest(c, converter.RasterPNG)
+	if err != nil {
+		return converter.GIFOptions{}, err
+	}
+	opts := converter.DefaultGIFOptions()
+	opts.Raster = raster
+	if value := formOrQuery(c, "gifColors"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return converter.GIFOptions{}, fmt.Errorf("invalid gifColors %q", value)
+		}
+		opts.Colors = parsed
+	}
+
+	if err := opts.Validate(); err != nil {
+		return converter.GIFOptions{}, err
+	}
+	return opts, nil
+}
+
+// videoEncodeFromRequest reads the drawing fields of rasterFromRequest, plus the optional
+// "container" (mp4 or webm), "crf" and "audio" (true to keep the source's audio track) fields
+// of a video export. The audio source is left to the handler.
+func videoEncodeFromRequest(c *fiber.Ctx) (converter.VideoEncodeOptions, bool, error) {
+	raster, err := rasterFromRequest(c, converter.RasterPNG)
+	if err != nil {
+		return converter.VideoEncodeOptions{}, false, err
+	}
+	opts := converter.DefaultVideoEncodeOptions()
+	opts.Raster = raster
+	if container := formOrQuery(c, "container"); container != "" {
+		opts.Format = container
+	}
+	if value := formOrQuery(c, "crf"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return converter.VideoEncodeOptions{}, false, fmt.Errorf("invalid crf %q", value)
+		}
+		opts.CRF = parsed
+	}
+
+	if err := opts.Validate(); err != nil {
+		return converter.VideoEncodeOptions{}, false, err
+	}
+	return opts, formOrQuery(c, "audio") == "true", nil
+}
+
+// fpsFromRequest reads the optional "fps" field: frames sampled per second of video, 1-15.
+// Missing or out-of-range values fall back to the default of 10.
+func fpsFromRequest(c *fiber.Ctx) int {
+	if fps, err := strconv.Atoi(c.FormValue("fps")); err == nil && fps > 0 && fps <= maxVideoFps {
+		return fps
+	}
+	return defaultVideoFps
+}
+
+// rasterFromRequest reads the "cellWidth", "cellHeight", "foreground", "background", "padding"
+// and "quality" fields (or query params) of an image export in format
+func rasterFromRequest(c *fiber.Ctx, format string) (converter.RasterOptions, error) {
+	opts := converter.DefaultRasterOptions()
+	opts.Format = format
+	ints := []struct {
+		key    string
+		target *int
+	}{
+		{"cellWidth", &opts.CellWidth},
+		{"cellHeight", &opts.CellHeight},
+		{"padding", &opts.Padding},
+		{"quality", &opts.Quality},
+	}
+	for _, i := range ints {
+		if value := formOrQuery(c, i.key); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return converter.RasterOptions{}, fmt.Errorf("invalid %s %q", i.key, value)
+			}
+			*i.target = parsed
+		}
+	}
+	colors := []struct {
+		key    string
+		target *converter.RGB
+	}{
+		{"background", &opts.Background},
+		{"foreground", &opts.Foreground},
+	}
+	for _, col := range colors {
+		if value := formOrQuery(c, col.key); value != "" {
+			parsed, err := converter.ParseHexColor(value)
+			if err != nil {
+				return converter.RasterOptions{}, err
+			}
+			*col.target = parsed
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return converter.RasterOptions{}, err
+	}
+	return opts, nil
+}
+
+// transformFromRequest reads the "crop", "rotate", "flipH" and "flipV" fields (or query params)
+func transformFromRequest(c *fiber.Ctx) (converter.TransformOptions, error) {
+	var transform converter.TransformOptions
+	crop, err := converter.ParseCrop(formOrQuery(c, "crop"))
+	if err != nil {
+		return converter.TransformOptions{}, err
+	}
+	transform.Crop = crop
+	if rotateStr := formOrQuery(c, "rotate"); rotateStr != "" {
+		parsed, err := strconv.Atoi(rotateStr)
+		if err != nil {
+			return converter.TransformOptions{}, fmt.Errorf("invalid rotate %q", rotateStr)
+		}
+		transform.Rotate = parsed
+	}
+	transform.FlipH = formOrQuery(c, "flipH") == "true"
+	transform.FlipV = formOrQuery(c, "flipV") == "true"
+
+	if err := transform.Validate(); err != nil {
+		return converter.TransformOptions{}, err
+	}
+	return transform, nil
+}
+
+// adjustmentsFromRequest reads the "brightness", "contrast", "gamma", "blackPoint",
+// "whitePoint" and "invert" fields (or query params)
+func adjustmentsFromRequest(c *fiber.Ctx) (converter.Adjustments, error) {
+	var adjust converter.Adjustments
+	floats := []struct {
+		key    string
+		target *float64
+	}{
+		{"brightness", &adjust.Brightness},
+		{"contrast", &adjust.Contrast},
+		{"gamma", &adjust.Gamma},
+	}
+	for _, f := range floats {
+		if value := formOrQuery(c, f.key); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return converter.Adjustments{}, fmt.Errorf("invalid %s %q", f.key, value)
+			}
+			*f.target = parsed
+		}
+	}
+
+	levels := []struct {
+		key    string
+		target *uint8
+	}{
+		{"blackPoint", &adjust.BlackPoint},
+		{"whitePoint", &adjust.WhitePoint},
+	}
+	for _, l := range levels {
+		if value := formOrQuery(c, l.key); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 || parsed > 255 {
+				return converter.Adjustments{}, fmt.Errorf("invalid %s %q (expected 0-255)", l.key, value)
+			}
+			*l.target = uint8(parsed)
+		}
+	}
+
+	adjust.Invert = formOrQuery(c, "invert") == "true"
+
+	if err := adjust.Validate(); err != nil {
+		return converter.Adjustments{}, err
+	}
+	return adjust, nil
+}
+
+// equalizeFromRequest reads the "equalize", "claheTile" and "claheClip" fields (or query params)
+func equalizeFromRequest(c *fiber.Ctx) (converter.EqualizeOptions, error) {
+	opts := converter.DefaultEqualizeOptions()
+	if method := formOrQuery(c, "equalize"); method != "" {
+		opts.Method = method
+	}
+	if tileStr := formOrQuery(c, "claheTile"); tileStr != "" {
+		parsed, err := strconv.Atoi(tileStr)
+		if err != nil {
+			return converter.EqualizeOptions{}, fmt.Errorf("invalid claheTile %q", tileStr)
+		}
+		opts.TileSize = parsed
+	}
+	if clipStr := formOrQuery(c, "claheClip"); clipStr != "" {
+		parsed, err := strconv.ParseFloat(clipStr, 64)
+		if err != nil {
+			return converter.EqualizeOptions{}, fmt.Errorf("invalid claheClip %q", clipStr)
+		}
+		opts.ClipLimit = parsed
+	}
+	if err := opts.Validate(); err != nil {
+		return converter.EqualizeOptions{}, err
+	}
+	return opts, nil
+}
+
+// filtersFromRequest reads the "filters" chain (e.g. "median:1,sharpen") and the
+// "filterStage" field (or query params)
+func filtersFromRequest(c *fiber.Ctx) (converter.FilterChain, error) {
+	filters, err := converter.ParseFilterChain(formOrQuery(c, "filters"))
+	if err != nil {
+		return converter.FilterChain{}, err
+	}
+	chain := converter.FilterChain{Filters: filters, Stage: formOrQuery(c, "filterStage")}
+	if err := chain.Validate(); err != nil {
+		return converter.FilterChain{}, err
+	}
+	return chain, nil
+}
+
+// alphaFromRequest reads the "alpha", "alphaBackground" and "alphaThreshold" fields (or query params)
+func alphaFromRequest(c *fiber.Ctx) (converter.AlphaOptions, error) {
+	opts := converter.DefaultAlphaOptions()
+	if mode := formOrQuery(c, "alpha"); mode != "" {
+		opts.Mode = mode
+	}
+	if bg := formOrQuery(c, "alphaBackground"); bg != "" {
+		parsed, err := converter.ParseHexColor(bg)
+		if err != nil {
+			return converter.AlphaOptions{}, err
+		}
+		opts.Background = parsed
+	}
+	if thresholdStr := formOrQuery(c, "alphaThreshold"); thresholdStr != "" {
+		parsed, err := strconv.Atoi(thresholdStr)
+		if err != nil {
+			return converter.AlphaOptions{}, fmt.Errorf("invalid alphaThreshold %q", thresholdStr)
+		}
+		opts.Threshold = clampUint8(parsed)
+	}
+	if err := opts.Validate(); err != nil {
+		return converter.AlphaOptions{}, err
+	}
+	return opts, nil
+}
+
+// clampUint8 limits v to the 0-255 range
+func clampUint8(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}